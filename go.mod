@@ -5,6 +5,7 @@ go 1.26
 retract v0.6.1 // init -s writes to stderr instead of stdout, breaking eval
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/caarlos0/go-version v0.2.2
 	github.com/fatih/color v1.19.0
 	github.com/google/go-cmp v0.7.0
@@ -0,0 +1,84 @@
+package arguments
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ResolveAlias returns the command with a git alias used as its subcommand
+// (e.g. "co" for "alias.co = checkout") replaced by the command line it stands
+// for, as git itself would run it, so that "git co 3" is treated exactly like
+// "git checkout 3". Aliases of aliases are resolved in turn, and an alias may
+// also supply global options (e.g. "alias.lg = -c color.ui=always log").
+//
+// lookup returns the command line of the alias name, and whether there is
+// one; it is responsible for excluding shell command aliases ("!...") and
+// names that git does not treat as an alias, such as builtin commands.
+func (c GitCommand) ResolveAlias(lookup func(name string) (string, bool)) GitCommand {
+	var seen []string
+	for {
+		subcommand := c.Subcommand()
+		if subcommand == "" || slices.Contains(seen, subcommand) {
+			return c // git refuses alias loops itself
+		}
+		seen = append(seen, subcommand)
+
+		value, ok := lookup(subcommand)
+		if !ok {
+			return c
+		}
+		words, err := splitCmdline(value)
+		if err != nil || len(words) == 0 {
+			return c // git will report the bad alias itself
+		}
+		args := slices.Concat(c.Globals, words, c.Args[1:])
+		c = ParseGitCommand(args)
+	}
+}
+
+// splitCmdline splits the command line of a git alias into words as git does:
+// on whitespace, except within single or double quotes, and with a backslash
+// escaping the following character outside of single quotes.
+func splitCmdline(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unclosed quote")
+	}
+	if escaped {
+		return nil, errors.New("unterminated escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
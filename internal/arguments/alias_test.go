@@ -0,0 +1,71 @@
+package arguments
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGitCommand_ResolveAlias(t *testing.T) {
+	aliases := map[string]string{
+		"co":      "checkout",
+		"unstage": "reset HEAD --",
+		"lg":      "-c color.ui=always log",
+		"cm":      `commit -m "work in progress"`,
+		"uns":     "unstage",
+		"loop":    "loop -v",
+		"bad":     `log "unclosed`,
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := aliases[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{args: "co 3", want: []string{"checkout", "3"}},
+		{args: "-C ../other co 3", want: []string{"-C", "../other", "checkout", "3"}},
+		{args: "unstage 1 2", want: []string{"reset", "HEAD", "--", "1", "2"}},
+		{args: "uns 1", want: []string{"reset", "HEAD", "--", "1"}},
+		{args: "lg -n 1", want: []string{"-c", "color.ui=always", "log", "-n", "1"}},
+		{args: "cm 1", want: []string{"commit", "-m", "work in progress", "1"}},
+		{args: "loop 1", want: []string{"loop", "-v", "1"}},
+		{args: "bad 1", want: []string{"bad", "1"}},
+		{args: "add 1", want: []string{"add", "1"}},
+		{args: "--version", want: []string{"--version"}},
+	}
+	for _, tt := range tests {
+		got := ParseGitCommand(strings.Fields(tt.args)).ResolveAlias(lookup)
+		if all := slices.Concat(got.Globals, got.Args); !slices.Equal(all, tt.want) {
+			t.Errorf("ResolveAlias(%q) = %q, want %q", tt.args, all, tt.want)
+		}
+	}
+}
+
+func Test_splitCmdline(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{s: "reset HEAD --", want: []string{"reset", "HEAD", "--"}},
+		{s: "  log\t--oneline  ", want: []string{"log", "--oneline"}},
+		{s: `commit -m "a b"`, want: []string{"commit", "-m", "a b"}},
+		{s: `log --format='%h %s'`, want: []string{"log", "--format=%h %s"}},
+		{s: `grep -e a\ b`, want: []string{"grep", "-e", "a b"}},
+		{s: `log --grep "say \"hi\""`, want: []string{"log", "--grep", `say "hi"`}},
+		{s: `log --grep ''`, want: []string{"log", "--grep", ""}},
+		{s: `log 'a\b'`, want: []string{"log", `a\b`}},
+		{s: `log "a`, wantErr: true},
+		{s: `log a\`, wantErr: true},
+		{s: "", want: nil},
+	}
+	for _, tt := range tests {
+		got, err := splitCmdline(tt.s)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("splitCmdline(%q) = %q, %v, want %q (error %v)", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
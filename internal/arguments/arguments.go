@@ -3,30 +3,91 @@
 package arguments
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/mroth/scmpuff/internal/shortcuts"
 )
 
 var (
 	expandArgDigitMatcher = regexp.MustCompile("^[0-9]{0,4}$")
 	expandArgRangeMatcher = regexp.MustCompile("^([0-9]+)-([0-9]+)$")
-	managedEnvVar         = regexp.MustCompile(`^\$e\d+$`)
 )
 
+// managedVarPatterns caches the patterns returned by managedVars, which are
+// compiled once per shortcut variable prefix.
+var managedVarPatterns sync.Map // prefix -> [2]*regexp.Regexp
+
+// managedVars returns the patterns matching scmpuff-managed position variables
+// (e.g. $e1), and those in the path portion of an object name (e.g. HEAD:$e1),
+// for the shortcut variable prefix in use (see shortcuts.Prefix).
+func managedVars() (envVar, objectEnvVar *regexp.Regexp) {
+	prefix := shortcuts.Prefix()
+	patterns, ok := managedVarPatterns.Load(prefix)
+	if !ok {
+		ref := `\$` + regexp.QuoteMeta(prefix) + `\d+`
+		patterns, _ = managedVarPatterns.LoadOrStore(prefix, [2]*regexp.Regexp{
+			regexp.MustCompile(`^` + ref + `$`),
+			regexp.MustCompile(`^(.*:)(` + ref + `)$`),
+		})
+	}
+	p := patterns.([2]*regexp.Regexp)
+	return p[0], p[1]
+}
+
+// A Lookup returns the path assigned to the scmpuff-managed position variable
+// name (e.g. "e1"), and whether it is assigned, such as os.LookupEnv.
+type Lookup func(name string) (string, bool)
+
 // EvaluateEnvironment evaluates a single arguments and expands environment
 // variables.
 //
 // For scmpuff-managed position variables only (e.g. $e1, etc), the variable is
-// expanded into a locatable file path, and expandRelative is true, it will be
-// converted into a relative path when possible.
-func EvaluateEnvironment(arg string, expandRelative bool) string {
-	expanded := os.ExpandEnv(arg)
+// resolved with lookup rather than the environment, so that shortcuts may be
+// scoped to a repository (see shortcuts.Resolver). It is then expanded into a
+// locatable file path, and if relativeTo is not empty, it will be converted into
+// a path relative to that directory when possible (see WorkingDir).
+//
+// For a position variable in the path portion of a "<rev>:<path>" object name
+// (e.g. HEAD:$e1), the path is always converted to be relative to the root of
+// the repository that the command runs in from dir (see WorkingDir, or the
+// current working directory if empty), as required by git for this syntax.
+func EvaluateEnvironment(arg string, dir string, relativeTo string, lookup Lookup) string {
+	managedEnvVar, managedObjectEnvVar := managedVars()
+	mapping := func(name string) string {
+		if managedEnvVar.MatchString("$" + name) {
+			path, _ := lookup(name)
+			return path
+		}
+		return os.Getenv(name)
+	}
+
+	if m := managedObjectEnvVar.FindStringSubmatch(arg); m != nil {
+		rev, envVar := m[1], m[2]
+		path := os.Expand(envVar, mapping)
+		if path == "" {
+			// "<rev>:" would name the root tree, so leave the shortcut as
+			// typed (e.g. "HEAD:3") for git to report
+			n, _ := shortcuts.ParseVarName(envVar[1:])
+			return rev + strconv.Itoa(n)
+		}
+		if rootRelPath, err := convertToRootRelative(path, dir); err == nil {
+			return rev + rootRelPath
+		}
+		return rev + path
+	}
+
+	expanded := os.Expand(arg, mapping)
 	wasChanged := (expanded != arg)
-	if wasChanged && expandRelative && managedEnvVar.MatchString(arg) {
-		relPath, err := convertToRelativeIfFilePath(expanded)
+	if wasChanged && relativeTo != "" && managedEnvVar.MatchString(arg) {
+		relPath, err := convertToRelativeIfFilePath(expanded, relativeTo)
 		if err == nil {
 			return relPath
 		}
@@ -34,120 +95,199 @@ func EvaluateEnvironment(arg string, expandRelative bool) string {
 	return expanded
 }
 
+// IsShortcut reports whether arg, as returned by Expand, references a
+// scmpuff-managed position variable (e.g. $e1, or HEAD:$e1), meaning it was
+// expanded from a numeric file shortcut.
+func IsShortcut(arg string) bool {
+	managedEnvVar, managedObjectEnvVar := managedVars()
+	return managedEnvVar.MatchString(arg) || managedObjectEnvVar.MatchString(arg)
+}
+
+// ShortcutVar returns the name of the scmpuff-managed position variable
+// referenced by arg, as returned by Expand (e.g. "e1" for $e1 or HEAD:$e1), and
+// whether arg references one at all.
+func ShortcutVar(arg string) (string, bool) {
+	managedEnvVar, managedObjectEnvVar := managedVars()
+	if m := managedObjectEnvVar.FindStringSubmatch(arg); m != nil {
+		arg = m[2]
+	}
+	if !managedEnvVar.MatchString(arg) {
+		return "", false
+	}
+	return arg[1:], true
+}
+
+// IsUnresolved reports whether arg, as returned by Expand, references a
+// scmpuff-managed position variable (e.g. $e1, or HEAD:$e1) that lookup has no
+// path for, so that EvaluateEnvironment cannot resolve it.
+func IsUnresolved(arg string, lookup Lookup) bool {
+	name, ok := ShortcutVar(arg)
+	if !ok {
+		return false
+	}
+	path, _ := lookup(name)
+	return path == ""
+}
+
 // For a given arg, try to determine if it represents a file, and if so, convert
-// it to a relative filepath.
+// it to a filepath relative to dir.
 //
 // Otherwise (or if any error conditions occur) return it unmolested.
-func convertToRelativeIfFilePath(arg string) (string, error) {
+func convertToRelativeIfFilePath(arg string, dir string) (string, error) {
 	_, err := os.Stat(arg)
 	if err != nil {
 		return arg, err
 	}
-	wd, err := os.Getwd()
+	relPath, err := filepath.Rel(dir, arg)
 	if err != nil {
 		return arg, err
 	}
-	relPath, err := filepath.Rel(wd, arg)
+	return relPath, nil
+}
+
+// convertToRootRelative converts an absolute path within the git repository
+// containing dir (or the current working directory if empty) to a slash
+// separated path relative to the repository root.
+//
+// The root is derived from dir rather than asking git for it, matching how the
+// paths stored in position variables are constructed by "scmpuff status", which
+// keeps symlinked working directories consistent.
+func convertToRootRelative(absPath string, dir string) (string, error) {
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		dir = wd
+	}
+	prefix, err := repoPrefix(dir)
 	if err != nil {
-		return arg, err
+		return "", err
 	}
-	return relPath, nil
+	relPath, err := filepath.Rel(dir, absPath)
+	if err != nil {
+		return "", err
+	}
+	rootRelPath := filepath.Join(prefix, relPath)
+	if rootRelPath == ".." || strings.HasPrefix(rootRelPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside of the repository", absPath)
+	}
+	return filepath.ToSlash(rootRelPath), nil
+}
+
+// repoPrefixes caches the results of repoPrefix by directory, as it is needed
+// for every object name argument of a command.
+var repoPrefixes sync.Map // directory -> prefix
+
+// repoPrefix returns the path of dir relative to the root of its repository, as
+// given by "git rev-parse --show-prefix" run there with the git binary in
+// SCMPUFF_GIT_CMD (or git from $PATH if unset).
+func repoPrefix(dir string) (string, error) {
+	if prefix, ok := repoPrefixes.Load(dir); ok {
+		return prefix.(string), nil
+	}
+	gitCmd := os.Getenv("SCMPUFF_GIT_CMD")
+	if gitCmd == "" {
+		gitCmd = "git"
+	}
+	cmd := exec.Command(gitCmd, "rev-parse", "--show-prefix")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine repository prefix: %w", err)
+	}
+	prefix := strings.TrimSuffix(string(out), "\n")
+	repoPrefixes.Store(dir, prefix)
+	return prefix, nil
 }
 
-// skipExpansion reports whether args[pos] should be left unexpanded.
+// argExpansions reports, for each of args, how it should be expanded.
 //
 // Normally, Expand treats any bare integer token as a file shortcut (e.g. "1"
-// becomes "$e1"). This is wrong when the integer is actually the value of a
-// preceding flag — for example, "git log -n 1" means "show one commit", but
-// naive expansion turns it into "git log -n $e1" which resolves to a filepath.
-// Similarly, "git checkout -b 713" is a branch name, not a file shortcut.
+// becomes "$e1"). This is wrong when the integer is not a path as far as git is
+// concerned — for example, "git log -n 1" means "show one commit", but naive
+// expansion turns it into "git log -n $e1" which resolves to a filepath.
+// Similarly, in "git checkout -b 713 42" both "713" (the new branch) and "42"
+// (its start-point) are revisions, not file shortcuts.
 //
-// We fix this by maintaining an allowlist of flags (per git subcommand) whose
-// space-separated values should never be expanded. Only the subcommands routed
-// through "scmpuff exec" by the shell wrapper need coverage, and only flags
-// that accept a space-separated value that could be numeric. Flags where the
-// value must be glued (e.g. "-U3") are not a problem because the combined
-// token doesn't match the digit regex.
+// We fix this by consulting a model of the command line for each git
+// subcommand routed through "scmpuff exec" by the shell wrapper (see
+// gitGrammars), which knows the options that take values and whether
+// positional arguments are revisions or pathspecs. Numbers are then only
+// expanded where git may expect a path — which includes the path portion of a
+// "<rev>:<path>" object name (e.g. "git show HEAD:3").
 //
-// Short flags may also be bundled into a single token, with the value-taking
-// flag last (e.g. "git commit -am 205" is "-a -m 205", so "205" is the
-// message). We detect this for the message flag via isBundledMessageFlag.
-//
-// Known limitation: positional numeric arguments that don't immediately follow
-// a flag (e.g. the start-point in "git checkout -b new 713") are not protected.
+// Users may supplement the built-in grammar with rules of their own (e.g. for
+// options we don't know about, or custom "git-foo" subcommands), see
+// NoExpandRules.
 //
 // gitCmd is the value of SCMPUFF_GIT_CMD; when args[0] matches it, we know
-// this is a git command routed through the shell wrapper.
-func skipExpansion(args []string, pos int, gitCmd string) bool {
-	if pos < 2 || gitCmd == "" || args[0] != gitCmd {
-		return false
+// this is a git command routed through the shell wrapper. Its global options
+// (e.g. "git -C ../other add 1") are never expanded, and the subcommand follows
+// them (see ParseGitCommand). A git subcommand with neither a built-in grammar
+// nor a user rule only has arguments after a "--" separator expanded (see
+// unknownGrammar). For any other command, every argument is expanded as a
+// potential file shortcut.
+func argExpansions(args []string, gitCmd string, rules NoExpandRules) []expansion {
+	result := make([]expansion, len(args)) // expandShortcuts by default
+	if len(args) < 2 || gitCmd == "" || args[0] != gitCmd {
+		return result
 	}
-	prev := args[pos-1]
-	switch args[1] {
-	case "log":
-		switch prev {
-		case "-n", "--max-count", "--skip", "--grep":
-			return true
-		}
-	case "commit":
-		switch prev {
-		case "--message":
-			return true
-		}
-		return isBundledMessageFlag(prev)
-	case "merge":
-		switch prev {
-		case "--message":
-			return true
-		}
-		return isBundledMessageFlag(prev)
-	case "checkout":
-		switch prev {
-		case "-b", "-B", "--orphan":
-			return true
-		}
-	case "blame":
-		switch prev {
-		case "-L":
-			return true
-		}
-	case "rebase":
-		switch prev {
-		case "-C", "--onto":
-			return true
-		}
+	git := ParseGitCommand(args[1:])
+	start := 1 + len(git.Globals)
+	for i := 1; i < start; i++ {
+		result[i] = expandNone
 	}
-	return false
+	if len(git.Args) > 0 {
+		copy(result[start:], rules.grammarFor(git.Subcommand()).expansions(git.Args))
+	}
+	return result
 }
 
-// isBundledMessageFlag reports whether flag is a short-flag token whose final
-// letter is "m" — the message flag for "git commit"/"git merge". This covers
-// the plain "-m" as well as bundled forms like "-am" or "-sam" (common via
-// aliases such as "gcam" => "git commit -am"), where the token after the flag
-// is the message and must not be expanded as a file shortcut.
+// NoExpandRules maps a git subcommand (e.g. "log") to additional options whose
+// space-separated value must never be expanded as a file shortcut. They
+// supplement the built-in grammar for known subcommands. For any other
+// subcommand, they are the only options considered to take a value, and having
+// any makes its positionals paths.
 //
-// It deliberately excludes long flags ("--foo") and glued values ("-m205",
-// which git reads as the message "205" in the same token, so there is no
-// separate token to protect).
-func isBundledMessageFlag(flag string) bool {
-	return strings.HasPrefix(flag, "-") &&
-		!strings.HasPrefix(flag, "--") &&
-		strings.HasSuffix(flag, "m")
+// A nil NoExpandRules is valid, and adds no rules.
+type NoExpandRules map[string][]string
+
+// grammarFor returns the command line grammar for a git subcommand, including
+// any user-defined rules, or unknownGrammar if there is neither.
+func (rules NoExpandRules) grammarFor(subcommand string) commandGrammar {
+	grammar, known := gitGrammars[subcommand]
+	extra, hasRules := rules[subcommand]
+	if !known && !hasRules {
+		return unknownGrammar
+	}
+	if hasRules {
+		// NOTE: clip so that appending can never write into the shared backing
+		// array of the built-in grammar.
+		grammar.valueOptions = append(slices.Clip(grammar.valueOptions), extra...)
+	}
+	return grammar
 }
 
 // Expand takes the list of arguments received from the command line and expands
 // them given our special case rules.
 //
 // It handles converting numeric file placeholders and range placeholders into
-// environment variable symbolic representation,
-func Expand(args []string) []string {
+// environment variable symbolic representation, except where rules (or the
+// built-in git grammar) indicate the argument is not a file path.
+func Expand(args []string, rules NoExpandRules) []string {
 	gitCmd := os.Getenv("SCMPUFF_GIT_CMD")
+	expansions := argExpansions(args, gitCmd, rules)
+	dir := WorkingDir(args, gitCmd, ".")
 	var results []string
 	for i, arg := range args {
-		if skipExpansion(args, i, gitCmd) {
+		switch expansions[i] {
+		case expandShortcuts:
+			results = append(results, expandArg(arg, dir)...)
+		case expandNone:
 			results = append(results, arg)
-		} else {
-			results = append(results, expandArg(arg)...)
+		case expandObjectPaths:
+			results = append(results, expandObjectArg(arg, dir)...)
 		}
 	}
 	return results
@@ -161,17 +301,20 @@ func Expand(args []string) []string {
 //
 // It's also possible that argument may represent a range, in which case it will
 // return multiple instances of environment variable placeholders.
-func expandArg(arg string) []string {
+//
+// dir is the directory the command runs in, where a file named like the
+// argument takes precedence over the shortcut.
+func expandArg(arg string, dir string) []string {
 
 	// ...is it a single digit?
 	dm := expandArgDigitMatcher.FindString(arg)
 	if dm != "" {
 		// dont expand if its actually a numerically named file or directory!
-		if _, err := os.Stat(dm); err == nil {
+		if _, err := os.Stat(filepath.Join(dir, dm)); err == nil {
 			return []string{arg} //return as-is
 		}
 
-		result := "$e" + dm
+		result := "$" + shortcuts.Prefix() + dm
 		return []string{result}
 	}
 
@@ -183,7 +326,7 @@ func expandArg(arg string) []string {
 
 		var results []string
 		for i := lo; i <= hi; i++ {
-			results = append(results, "$e"+strconv.Itoa(i))
+			results = append(results, "$"+shortcuts.VarName(i))
 		}
 		return results
 	}
@@ -191,3 +334,27 @@ func expandArg(arg string) []string {
 	// if it was neither, return as-is
 	return []string{arg}
 }
+
+// expandObjectArg "expands" a single argument that is a git object name, which
+// may take the "<rev>:<path>" form, where only the path may be a numeric file
+// placeholder or range, e.g. "HEAD:3" becomes "HEAD:$e3", and "HEAD:1-2"
+// becomes "HEAD:$e1 HEAD:$e2". Any other argument is returned as-is.
+//
+// See EvaluateEnvironment for how the resulting path is resolved, as git
+// requires a path relative to the repository root in this syntax.
+func expandObjectArg(arg string, dir string) []string {
+	i := strings.LastIndexByte(arg, ':')
+	if i == -1 {
+		return []string{arg}
+	}
+	rev, path := arg[:i+1], arg[i+1:]
+	if !expandArgDigitMatcher.MatchString(path) && !expandArgRangeMatcher.MatchString(path) {
+		return []string{arg}
+	}
+
+	var results []string
+	for _, expanded := range expandArg(path, dir) {
+		results = append(results, rev+expanded)
+	}
+	return results
+}
@@ -24,7 +24,7 @@ func TestExpand(t *testing.T) {
 		// normal looking strings
 		args := strings.Split(tc.args, " ")
 		expected := strings.Split(tc.expected, " ")
-		actual := Expand(args)
+		actual := Expand(args, nil)
 		if !reflect.DeepEqual(actual, expected) {
 			t.Fatalf("ExpandArgs(%v): expected %v, actual %v", tc.args, expected, actual)
 		}
@@ -51,7 +51,6 @@ var testExpandNumericFlagCases = []struct {
 	{"git log --skip 1 2", "git log --skip 1 $e2"},
 	{"git log --grep 1 2", "git log --grep 1 $e2"},
 	{"git blame -L 1 1", "git blame -L 1 $e1"},
-	{"git rebase -C 3 1", "git rebase -C 3 $e1"},
 	{"git rebase --onto 713 main topic", "git rebase --onto 713 main topic"},
 
 	// Flags that take a string value that could happen to be all digits.
@@ -83,6 +82,80 @@ var testExpandNumericFlagCases = []struct {
 	// Same flag name, different subcommand: -n on "git rm" is --dry-run and
 	// takes no value, so the following "1" is a file shortcut.
 	{"git rm -n 1", "git rm -n $e1"},
+
+	// Positional slots where git expects a revision rather than a path.
+	{"git checkout -b new 713", "git checkout -b new 713"},
+	{"git reset --hard 1", "git reset --hard 1"},
+	{"git rebase -C 3 1", "git rebase -C 3 1"},
+	{"git merge 42", "git merge 42"},
+
+	// Positionals that may be either a revision or a path are expanded, unless
+	// a "--" separator tells git that everything before it is a revision.
+	{"git diff 1", "git diff $e1"},
+	{"git checkout 713 -- 1", "git checkout 713 -- $e1"},
+	{"git log 713 -- 1-2", "git log 713 -- $e1 $e2"},
+
+	// Nothing after "--" is an option, even if it looks like one.
+	{"git add -- -m 1", "git add -- -m $e1"},
+
+	// Object names: only the path portion of "<rev>:<path>" is expanded.
+	{"git show HEAD:3 4", "git show HEAD:$e3 4"},
+	{"git show main:1-2", "git show main:$e1 main:$e2"},
+	{"git show HEAD -- 1", "git show HEAD -- $e1"},
+	{"git cat-file -p HEAD~1:2", "git cat-file -p HEAD~1:$e2"},
+	{"git restore --source HEAD:1 2", "git restore --source HEAD:$e1 $e2"},
+	{"git restore --source=HEAD:1 2", "git restore --source=HEAD:$e1 $e2"},
+	{"git log HEAD:1", "git log HEAD:1"},
+	{"git restore --source HEAD~1 1", "git restore --source HEAD~1 $e1"},
+
+	// Interactive modes take paths as usual.
+	{"git add -p 1 2", "git add -p $e1 $e2"},
+
+	// Branch names are never paths.
+	{"git switch 713", "git switch 713"},
+	{"git switch -c 713 1", "git switch -c 713 1"},
+
+	// Renaming and cleaning only take paths.
+	{"git mv 1 dir", "git mv $e1 dir"},
+	{"git clean -n -e 1 2", "git clean -n -e 1 $e2"},
+
+	// The first positional of grep is its pattern, unless given by -e or -f.
+	{"git grep 1 2", "git grep 1 $e2"},
+	{"git grep -i 1 2", "git grep -i 1 $e2"},
+	{"git grep -C 3 1 2", "git grep -C 3 1 $e2"},
+	{"git grep -e 1 2", "git grep -e 1 $e2"},
+	{"git grep -ie 1 2", "git grep -ie 1 $e2"},
+	{"git grep -efoo 1", "git grep -efoo $e1"},
+	{"git grep 1 HEAD -- 2", "git grep 1 HEAD -- $e2"},
+
+	// Only stash push takes paths, also as the implicit push of a bare "--";
+	// the other stash subcommands take stash entries, where a number is an
+	// index.
+	{"git stash push 1 2", "git stash push $e1 $e2"},
+	{"git stash push -m 1 2", "git stash push -m 1 $e2"},
+	{"git stash push -- 1", "git stash push -- $e1"},
+	{"git stash drop 1", "git stash drop 1"},
+	{"git stash show -p 1", "git stash show -p 1"},
+	{"git stash -- 1", "git stash -- $e1"},
+	{"git stash -p -m 1 -- 2", "git stash -p -m 1 -- $e2"},
+	{"git stash drop -- 1", "git stash drop -- 1"},
+
+	// Global options precede the subcommand, and their values are never
+	// shortcuts.
+	{"git -C ../other add 1", "git -C ../other add $e1"},
+	{"git -C 2 log -n 1 3", "git -C 2 log -n 1 $e3"},
+	{"git -c core.pager=cat diff 2", "git -c core.pager=cat diff $e2"},
+	{"git --no-pager diff 3", "git --no-pager diff $e3"},
+	{"git --git-dir .git --work-tree . checkout 713 -- 1", "git --git-dir .git --work-tree . checkout 713 -- $e1"},
+	{"git --git-dir=.git -p log -n 1", "git --git-dir=.git -p log -n 1"},
+	{"git -C", "git -C"},
+
+	// Nothing is known of other subcommands, which may still be wrapped, so
+	// only numbers after a "--" separator are shortcuts.
+	{"git bisect good 1", "git bisect good 1"},
+	{"git ls-files -o -- 1 2", "git ls-files -o -- $e1 $e2"},
+	{"git ls-files 1 -- 2", "git ls-files 1 -- 2"},
+	{"git --version", "git --version"},
 }
 
 func TestExpandNumericFlags(t *testing.T) {
@@ -91,12 +164,47 @@ func TestExpandNumericFlags(t *testing.T) {
 		t.Run(tc.args, func(t *testing.T) {
 			args := strings.Split(tc.args, " ")
 			expected := strings.Split(tc.expected, " ")
-			actual := Expand(args)
+			actual := Expand(args, nil)
+			if !slices.Equal(actual, expected) {
+				t.Errorf("expected %v, actual %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpandNoExpandRules(t *testing.T) {
+	t.Setenv("SCMPUFF_GIT_CMD", "git")
+	rules := NoExpandRules{
+		"log": {"--foo"},
+		"bar": {"-x"},
+	}
+
+	tests := []struct {
+		args, expected string
+	}{
+		// user rules supplement the built-in grammar for known subcommands
+		{"git log --foo 1 2", "git log --foo 1 $e2"},
+		{"git log -n 1 2", "git log -n 1 $e2"},
+		// and define one for unknown (e.g. custom "git-bar") subcommands
+		{"git bar -x 1 2", "git bar -x 1 $e2"},
+		// without affecting other subcommands
+		{"git diff --foo 1", "git diff --foo $e1"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.args, func(t *testing.T) {
+			args := strings.Split(tc.args, " ")
+			expected := strings.Split(tc.expected, " ")
+			actual := Expand(args, rules)
 			if !slices.Equal(actual, expected) {
 				t.Errorf("expected %v, actual %v", expected, actual)
 			}
 		})
 	}
+
+	// the built-in grammar must not be modified by merging rules into it
+	if slices.Contains(gitGrammars["log"].valueOptions, "--foo") {
+		t.Errorf("built-in log grammar was modified by user rules")
+	}
 }
 
 // Expansion of a single arg, which might still be a range
@@ -111,7 +219,7 @@ var testExpandArgCases = []struct {
 
 func TestExpandArg(t *testing.T) {
 	for _, tc := range testExpandArgCases {
-		actual := expandArg(tc.arg)
+		actual := expandArg(tc.arg, ".")
 		if !reflect.DeepEqual(actual, tc.expected) {
 			t.Fatalf("ExpandArg(%v): expected %v, actual %v", tc.arg, tc.expected, actual)
 		}
@@ -146,25 +254,91 @@ func TestEvaluateEnvironment(t *testing.T) {
 	t.Logf("$e2=%v", os.Getenv("e2"))
 
 	tests := []struct {
-		name           string
-		arg            string
-		expandRelative bool
-		want           string
+		name       string
+		arg        string
+		relativeTo string
+		want       string
 	}{
-		{name: "not an env var", arg: "eee", expandRelative: false, want: "eee"},
-		{name: "not file absolute", arg: "$FOO_USER", expandRelative: false, want: "not_a_file"},
-		{name: "not file relative", arg: "$FOO_USER", expandRelative: true, want: "not_a_file"},
-		{name: "absolute file", arg: "$e1", expandRelative: false, want: filepath.Join(wd, "testdata", "a.txt")},
-		{name: "relative file", arg: "$e1", expandRelative: true, want: filepath.FromSlash("testdata/a.txt")},
-		{name: "path binary dont convert relative - abs", arg: "$SCMPUFF_GIT_CMD", expandRelative: false, want: fakegitAbsPath},
-		{name: "path binary dont convert relative - rel", arg: "$SCMPUFF_GIT_CMD", expandRelative: true, want: fakegitAbsPath},
+		{name: "not an env var", arg: "eee", want: "eee"},
+		{name: "not file absolute", arg: "$FOO_USER", want: "not_a_file"},
+		{name: "not file relative", arg: "$FOO_USER", relativeTo: wd, want: "not_a_file"},
+		{name: "absolute file", arg: "$e1", want: filepath.Join(wd, "testdata", "a.txt")},
+		{name: "relative file", arg: "$e1", relativeTo: wd, want: filepath.FromSlash("testdata/a.txt")},
+		{name: "relative file to other dir", arg: "$e1", relativeTo: filepath.Join(wd, "testdata", "bin"), want: filepath.FromSlash("../a.txt")},
+		{name: "path binary dont convert relative - abs", arg: "$SCMPUFF_GIT_CMD", want: fakegitAbsPath},
+		{name: "path binary dont convert relative - rel", arg: "$SCMPUFF_GIT_CMD", relativeTo: wd, want: fakegitAbsPath},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := EvaluateEnvironment(tt.arg, tt.expandRelative); got != tt.want {
-				t.Errorf("EvaluateEnvironment(%v, %v) = %v, want %v", tt.arg, tt.expandRelative, got, tt.want)
+			if got := EvaluateEnvironment(tt.arg, "", tt.relativeTo, os.LookupEnv); got != tt.want {
+				t.Errorf("EvaluateEnvironment(%v, %v) = %v, want %v", tt.arg, tt.relativeTo, got, tt.want)
 			}
 		})
 	}
 }
+
+func TestShortcutVar(t *testing.T) {
+	tests := []struct {
+		arg    string
+		want   string
+		wantOk bool
+	}{
+		{arg: "$e1", want: "e1", wantOk: true},
+		{arg: "$e42", want: "e42", wantOk: true},
+		{arg: "HEAD:$e3", want: "e3", wantOk: true},
+		{arg: "e1", wantOk: false},
+		{arg: "$FOO", wantOk: false},
+		{arg: "$e1x", wantOk: false},
+		{arg: "HEAD:README", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ShortcutVar(tt.arg)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("ShortcutVar(%q) = %q, %v; want %q, %v", tt.arg, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestEvaluateEnvironmentLookup(t *testing.T) {
+	t.Setenv("e1", "/from/environment")
+	t.Setenv("FOO_USER", "not_a_file")
+	lookup := func(name string) (string, bool) {
+		if name == "e1" {
+			return "/from/lookup", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "$e1", want: "/from/lookup"},
+		{arg: "$e2", want: ""},
+		{arg: "HEAD:$e2", want: "HEAD:2"},      // not the root tree of HEAD
+		{arg: "$FOO_USER", want: "not_a_file"}, // other variables still come from the environment
+	}
+	for _, tt := range tests {
+		if got := EvaluateEnvironment(tt.arg, "", "", lookup); got != tt.want {
+			t.Errorf("EvaluateEnvironment(%v) = %v, want %v", tt.arg, got, tt.want)
+		}
+	}
+	if !IsUnresolved("$e2", lookup) || IsUnresolved("$e1", lookup) {
+		t.Errorf("IsUnresolved() did not use lookup")
+	}
+}
+
+func TestExpandPrefix(t *testing.T) {
+	t.Setenv("SCMPUFF_PREFIX", "sp_")
+
+	got := Expand([]string{"git", "add", "1", "2-3", "e4"}, nil)
+	want := []string{"git", "add", "$sp_1", "$sp_2", "$sp_3", "e4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expand() = %v, want %v", got, want)
+	}
+	if !IsShortcut("$sp_1") || !IsShortcut("HEAD:$sp_1") || IsShortcut("$e1") {
+		t.Errorf("IsShortcut() does not respect prefix")
+	}
+}
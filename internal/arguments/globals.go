@@ -0,0 +1,90 @@
+package arguments
+
+import (
+	"path/filepath"
+	"slices"
+)
+
+// globalValueOptions are the git global options that take their value as a
+// separate argument, e.g. "git -C ../other status". Options given their value
+// with "=" (e.g. "--git-dir=.git") and all others are a single argument.
+var globalValueOptions = []string{
+	"-C", "-c", "--config-env", "--git-dir", "--namespace", "--super-prefix", "--work-tree",
+}
+
+// A GitCommand is a git command line, without the git command itself, split
+// at its subcommand.
+type GitCommand struct {
+	Globals []string // global options preceding the subcommand, along with their values
+	Args    []string // the subcommand followed by its arguments, if any
+}
+
+// ParseGitCommand splits the arguments of a git command line (e.g. "-C ../other
+// add 1", excluding the git command itself) into its global options and the
+// subcommand with its arguments. The subcommand is the first argument that is
+// neither a global option nor the value of one.
+func ParseGitCommand(args []string) GitCommand {
+	i := 0
+	for i < len(args) && isOption(args[i]) {
+		if slices.Contains(globalValueOptions, args[i]) {
+			i++ // skip value
+		}
+		i++
+	}
+	i = min(i, len(args))
+	return GitCommand{Globals: args[:i], Args: args[i:]}
+}
+
+// Subcommand returns the git subcommand, e.g. "add", or "" if there is none.
+func (c GitCommand) Subcommand() string {
+	if len(c.Args) == 0 {
+		return ""
+	}
+	return c.Args[0]
+}
+
+// HasOption reports whether any of the named options (e.g. "-S", "--staged")
+// is given to the subcommand before any "--". Options are recognized as bundled
+// short flags (e.g. "-SW") too, and with glued values (e.g. "--source=HEAD"),
+// following the grammar of the subcommand.
+func (c GitCommand) HasOption(names ...string) bool {
+	if len(c.Args) == 0 {
+		return false
+	}
+	opts := NoExpandRules(nil).grammarFor(c.Args[0]).options(c.Args)
+	return slices.ContainsFunc(opts, func(opt string) bool {
+		return slices.Contains(names, opt)
+	})
+}
+
+// Dir returns the directory git is run in, given the directory it is invoked
+// from. Each "-C <path>" global option changes it, relative to the previous
+// one, as git does, and an empty path leaves it unchanged.
+func (c GitCommand) Dir(wd string) string {
+	for i := 0; i < len(c.Globals)-1; i++ {
+		opt, value := c.Globals[i], c.Globals[i+1]
+		if !slices.Contains(globalValueOptions, opt) {
+			continue
+		}
+		i++ // skip value
+		if opt != "-C" || value == "" {
+			continue
+		}
+		if filepath.IsAbs(value) {
+			wd = value
+		} else {
+			wd = filepath.Join(wd, value)
+		}
+	}
+	return wd
+}
+
+// WorkingDir returns the directory that the command line args runs in, given
+// the current working directory wd. This is wd, unless args is a git command
+// (see argExpansions) with "-C" global options.
+func WorkingDir(args []string, gitCmd, wd string) string {
+	if len(args) < 2 || gitCmd == "" || args[0] != gitCmd {
+		return wd
+	}
+	return ParseGitCommand(args[1:]).Dir(wd)
+}
@@ -0,0 +1,78 @@
+package arguments
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGitCommand(t *testing.T) {
+	tests := []struct {
+		args        string
+		wantGlobals string
+		wantArgs    string
+	}{
+		{args: "add 1", wantArgs: "add 1"},
+		{args: "-C ../other add 1", wantGlobals: "-C ../other", wantArgs: "add 1"},
+		{args: "-c core.pager=cat --no-pager diff -C 2", wantGlobals: "-c core.pager=cat --no-pager", wantArgs: "diff -C 2"},
+		{args: "--git-dir=.git --work-tree . status", wantGlobals: "--git-dir=.git --work-tree .", wantArgs: "status"},
+		{args: "--version", wantGlobals: "--version"},
+		{args: "-C", wantGlobals: "-C"},
+		{args: ""},
+	}
+	for _, tt := range tests {
+		got := ParseGitCommand(strings.Fields(tt.args))
+		if !slices.Equal(got.Globals, strings.Fields(tt.wantGlobals)) || !slices.Equal(got.Args, strings.Fields(tt.wantArgs)) {
+			t.Errorf("ParseGitCommand(%q) = %q, %q, want %q, %q", tt.args, got.Globals, got.Args, tt.wantGlobals, tt.wantArgs)
+		}
+	}
+}
+
+func TestGitCommand_HasOption(t *testing.T) {
+	tests := []struct {
+		args  string
+		names []string
+		want  bool
+	}{
+		{args: "restore --staged 1", names: []string{"-S", "--staged"}, want: true},
+		{args: "restore -SW 1", names: []string{"-W"}, want: true},
+		{args: "restore --source=HEAD 1", names: []string{"--source"}, want: true},
+		{args: "restore -sHEAD 1", names: []string{"-H"}, want: false}, // glued value
+		{args: "restore -s -S 1", names: []string{"-S"}, want: false},  // separate value
+		{args: "restore -- -S 1", names: []string{"-S"}, want: false},  // a path
+		{args: "-C -n clean 1", names: []string{"-n"}, want: false},    // a global option value
+		{args: "clean -dn 1", names: []string{"-n", "--dry-run"}, want: true},
+		{args: "", names: []string{"-n"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := ParseGitCommand(strings.Fields(tt.args)).HasOption(tt.names...); got != tt.want {
+			t.Errorf("ParseGitCommand(%q).HasOption(%q) = %v, want %v", tt.args, tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestGitCommand_Dir(t *testing.T) {
+	wd := filepath.FromSlash("/repo/sub")
+	tests := []struct {
+		args string
+		want string
+	}{
+		{args: "add 1", want: "/repo/sub"},
+		{args: "-C .. add 1", want: "/repo"},
+		{args: "-C .. -C other add", want: "/repo/other"},
+		{args: "-C /elsewhere -C other add", want: "/elsewhere/other"},
+		{args: "-c -C=1 add", want: "/repo/sub"},
+		{args: "-C", want: "/repo/sub"},
+	}
+	for _, tt := range tests {
+		if got := ParseGitCommand(strings.Fields(tt.args)).Dir(wd); got != filepath.FromSlash(tt.want) {
+			t.Errorf("ParseGitCommand(%q).Dir(%q) = %q, want %q", tt.args, wd, got, tt.want)
+		}
+	}
+
+	// an empty path leaves the directory unchanged
+	if got := ParseGitCommand([]string{"-C", "", "status"}).Dir(wd); got != wd {
+		t.Errorf("Dir() with empty -C = %q, want %q", got, wd)
+	}
+}
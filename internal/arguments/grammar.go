@@ -0,0 +1,414 @@
+package arguments
+
+import (
+	"slices"
+	"strings"
+)
+
+// slot describes how git interprets a positional (non-option) argument, which
+// determines whether a numeric token in that position may be a file shortcut.
+type slot int
+
+const (
+	// slotPath is a pathspec. Numbers are file shortcuts.
+	slotPath slot = iota
+
+	// slotRevision is a commit-ish, branch name, or other non-path value.
+	// Numbers are left as-is.
+	slotRevision
+
+	// slotEither is a revision or a path, which git disambiguates at runtime
+	// (e.g. "git diff <rev>" vs "git diff <path>"). Numbers are treated as
+	// file shortcuts, unless an explicit "--" separator appears later in the
+	// command line, in which case git treats everything before it as a
+	// revision.
+	slotEither
+
+	// slotObject is an object name, which may take the "<rev>:<path>" form
+	// (e.g. "HEAD:README.md"). Only a number in the path portion is a file
+	// shortcut.
+	slotObject
+)
+
+// expansion is how a single command line token is to be expanded, as
+// determined by a commandGrammar.
+type expansion int
+
+const (
+	expandShortcuts   expansion = iota // numbers and ranges are file shortcuts
+	expandNone                         // left as-is
+	expandObjectPaths                  // only the path in "<rev>:<N>" is a file shortcut
+)
+
+// commandGrammar is a simplified model of the command line accepted by a
+// single git subcommand. It only needs to be detailed enough to tell which
+// tokens git will read as paths.
+type commandGrammar struct {
+	// valueOptions are the options that consume a value, which may either be
+	// glued to the option ("-n1", "--max-count=1") or passed as the following
+	// token ("-n 1", "--max-count 1"). Only the latter form matters here, as
+	// the glued form never looks like a bare number.
+	//
+	// Short options are listed in "-x" form, and are also recognized as the
+	// final member of a bundle of short flags (e.g. "-am" for "-a -m").
+	valueOptions []string
+
+	// positionals is how positional arguments before any "--" separator are
+	// interpreted.
+	positionals slot
+
+	// revisionModeOptions are options that, when present, mean git takes no
+	// pathspec at all, so that every positional is a revision. For example,
+	// "git checkout -b <branch> [<start-point>]" or "git reset --hard [<commit>]".
+	revisionModeOptions []string
+
+	// objectOptions are the value-taking options whose value is an object
+	// name, and may thus take the "<rev>:<path>" form (see slotObject).
+	objectOptions []string
+
+	// patternOptions are set for commands whose first positional argument is a
+	// pattern rather than a path (e.g. "git grep <pattern> [<path>...]"),
+	// unless one of these options supplies the pattern instead.
+	patternOptions []string
+
+	// separatorPaths is set for commands whose positionals are revisions, but
+	// which read any positionals after a "--" separator as paths when none
+	// come before it (e.g. "git stash -- <path>", an implicit "stash push").
+	separatorPaths bool
+
+	// subcommands are the grammars of subcommands of this command (e.g. "git
+	// stash push"), which apply to the rest of the command line when its first
+	// positional argument names one of them.
+	subcommands map[string]commandGrammar
+}
+
+// diffValueOptions are the value-taking options shared by the diff family of
+// commands (diff, difftool, log).
+var diffValueOptions = []string{
+	"-G", "-I", "-O", "-S",
+	"--anchored", "--diff-algorithm", "--diff-filter", "--dst-prefix",
+	"--find-object", "--ignore-matching-lines", "--inter-hunk-context",
+	"--line-prefix", "--output", "--output-indicator-context",
+	"--output-indicator-new", "--output-indicator-old", "--src-prefix",
+	"--stat-count", "--stat-graph-width", "--stat-name-width", "--stat-width",
+	"--word-diff-regex",
+}
+
+// revisionValueOptions are the value-taking revision walking options accepted
+// by log.
+var revisionValueOptions = []string{
+	"-L", "-n",
+	"--after", "--author", "--before", "--committer", "--encoding", "--grep",
+	"--grep-reflog", "--max-age", "--max-count", "--min-age", "--since",
+	"--since-as-filter", "--skip", "--until",
+}
+
+// gitGrammars maps each git subcommand routed through "scmpuff exec" by the
+// shell wrapper to a model of its command line.
+//
+// NOTE: When adding a subcommand to the shell wrapper dispatch table (see
+// config.DefaultWrapRules), add it here as well, otherwise numeric tokens will
+// only be expanded after a "--" separator (see unknownGrammar).
+var gitGrammars = map[string]commandGrammar{
+	"add": {
+		valueOptions: []string{"--chmod", "--pathspec-from-file"},
+		positionals:  slotPath,
+	},
+	"blame": {
+		valueOptions: []string{"-L", "-S", "--contents", "--ignore-rev", "--ignore-revs-file"},
+		positionals:  slotEither,
+	},
+	"cat-file": {
+		valueOptions: []string{"--path"},
+		positionals:  slotObject,
+	},
+	"checkout": {
+		valueOptions:        []string{"-b", "-B", "--conflict", "--orphan", "--pathspec-from-file"},
+		positionals:         slotEither,
+		revisionModeOptions: []string{"-b", "-B", "--detach", "--orphan"},
+	},
+	"clean": {
+		valueOptions: []string{"-e", "--exclude"},
+		positionals:  slotPath,
+	},
+	"commit": {
+		valueOptions: []string{
+			"-c", "-C", "-F", "-m", "-t",
+			"--author", "--cleanup", "--date", "--file", "--fixup", "--message",
+			"--pathspec-from-file", "--reedit-message", "--reuse-message",
+			"--squash", "--template", "--trailer",
+		},
+		positionals: slotPath,
+	},
+	"diff": {
+		valueOptions: diffValueOptions,
+		positionals:  slotEither,
+	},
+	"difftool": {
+		valueOptions: slices.Concat(diffValueOptions, []string{"-t", "-x", "--extcmd", "--tool"}),
+		positionals:  slotEither,
+	},
+	"grep": {
+		valueOptions: []string{
+			"-A", "-B", "-C", "-e", "-f", "-m",
+			"--after-context", "--before-context", "--context", "--max-count",
+			"--max-depth", "--threads",
+		},
+		positionals:    slotEither,
+		patternOptions: []string{"-e", "-f"},
+	},
+	"log": {
+		valueOptions: slices.Concat(diffValueOptions, revisionValueOptions),
+		positionals:  slotEither,
+	},
+	"merge": {
+		valueOptions: []string{
+			"-F", "-m", "-s", "-X",
+			"--cleanup", "--file", "--into-name", "--message", "--strategy",
+			"--strategy-option",
+		},
+		positionals: slotRevision,
+	},
+	"mergetool": {
+		valueOptions: []string{"-t", "--tool"},
+		positionals:  slotPath,
+	},
+	"mv": {
+		positionals: slotPath,
+	},
+	"rebase": {
+		valueOptions: []string{
+			"-C", "-s", "-x", "-X",
+			"--empty", "--exec", "--onto", "--strategy", "--strategy-option",
+			"--trailer", "--whitespace",
+		},
+		positionals: slotRevision,
+	},
+	"reset": {
+		valueOptions:        []string{"--pathspec-from-file"},
+		positionals:         slotEither,
+		revisionModeOptions: []string{"--hard", "--keep", "--merge", "--soft"},
+	},
+	"restore": {
+		valueOptions:  []string{"-s", "--conflict", "--pathspec-from-file", "--source"},
+		positionals:   slotPath,
+		objectOptions: []string{"-s", "--source"},
+	},
+	"rm": {
+		valueOptions: []string{"--pathspec-from-file"},
+		positionals:  slotPath,
+	},
+	"show": {
+		valueOptions: slices.Concat(diffValueOptions, revisionValueOptions),
+		positionals:  slotObject,
+	},
+	"stash": {
+		// the positionals of other stash subcommands are stash entries, where
+		// a number is an index (e.g. "git stash drop 1"), and without one, the
+		// options are those of an implicit push (e.g. "git stash -p -- 1")
+		valueOptions:   []string{"-m", "--message", "--pathspec-from-file"},
+		positionals:    slotRevision,
+		separatorPaths: true,
+		subcommands: map[string]commandGrammar{
+			"push": {
+				valueOptions: []string{"-m", "--message", "--pathspec-from-file"},
+				positionals:  slotPath,
+			},
+		},
+	},
+	"switch": {
+		valueOptions: []string{"-c", "-C", "--conflict", "--orphan"},
+		positionals:  slotRevision,
+	},
+}
+
+// unknownGrammar is the grammar of any other git subcommand, which users may
+// still add to the wrapper dispatch table (e.g. "git bisect good 1"). As
+// nothing is known of its options, the only numbers that are surely meant as
+// file shortcuts are those after a "--" separator (e.g. "git ls-files -- 1").
+var unknownGrammar = commandGrammar{positionals: slotRevision, separatorPaths: true}
+
+// takesValue reports whether opt is an option that consumes the following
+// token as its value.
+//
+// Long options must match exactly (a glued "--opt=value" never consumes the
+// next token). Short options may be bundled, in which case git reads letters
+// left to right until it reaches one that takes a value: any remaining letters
+// are that option's glued value, otherwise the following token is. So "-am"
+// consumes the next token, while "-ma" (message "a") does not.
+func (g commandGrammar) takesValue(opt string) bool {
+	if strings.HasPrefix(opt, "--") {
+		return slices.Contains(g.valueOptions, opt)
+	}
+
+	for i, letter := range opt[1:] {
+		if slices.Contains(g.valueOptions, "-"+string(letter)) {
+			return i == len(opt)-2 // only if nothing is glued after it
+		}
+	}
+	return false
+}
+
+// expansions classifies every token in args, which must start with the git
+// subcommand (e.g. ["log", "-n", "1", "2"]), and reports how each of them is to
+// be expanded. Options and their values, and positionals that git will read as
+// revisions rather than paths, are not expanded at all.
+func (g commandGrammar) expansions(args []string) []expansion {
+	result := make([]expansion, len(args))
+	result[0] = expandNone // the subcommand itself
+
+	if i := g.firstPositional(args); i != -1 {
+		if sub, ok := g.subcommands[args[i]]; ok {
+			for j := range i {
+				result[j] = expandNone
+			}
+			copy(result[i:], sub.expansions(args[i:]))
+			return result
+		}
+	}
+	patternPending := g.patternOptions != nil
+
+	// Options are only recognized up to the first "--", which also settles
+	// whether ambiguous positionals are revisions or paths.
+	isPositional := make([]bool, len(args))
+	positionals := g.positionals
+	separator := -1
+	for i := 1; i < len(args) && separator == -1; i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			separator = i
+			result[i] = expandNone
+		case isOption(arg):
+			result[i] = expandNone
+			if slices.Contains(g.revisionModeOptions, arg) {
+				positionals = slotRevision
+			}
+			if g.suppliesPattern(arg) {
+				patternPending = false
+			}
+			// NOTE: a glued long option value (e.g. "--source=HEAD:3") is
+			// handled by the "<rev>:<N>" matching as well, since the path
+			// portion is still at the end of the token.
+			opt, _, glued := strings.Cut(arg, "=")
+			if slices.Contains(g.objectOptions, opt) {
+				if glued {
+					result[i] = expandObjectPaths
+				} else if i+1 < len(args) {
+					i++
+					result[i] = expandObjectPaths
+					continue
+				}
+			}
+			if g.takesValue(arg) && i+1 < len(args) {
+				i++
+				result[i] = expandNone
+			}
+		case patternPending:
+			result[i] = expandNone
+			patternPending = false
+		default:
+			isPositional[i] = true
+		}
+	}
+	if positionals == slotEither && separator != -1 {
+		positionals = slotRevision
+	}
+	afterSeparator := expandShortcuts
+	if g.positionals == slotRevision && (!g.separatorPaths || slices.Contains(isPositional, true)) {
+		afterSeparator = expandNone
+	}
+
+	for i := 1; i < len(args); i++ {
+		switch {
+		case separator != -1 && i > separator:
+			result[i] = afterSeparator
+		case isPositional[i]:
+			result[i] = positionals.expansion()
+		}
+	}
+	return result
+}
+
+// suppliesPattern reports whether opt is one of the patternOptions, including
+// as the value-taking member of a bundle of short flags (e.g. "-ie").
+func (g commandGrammar) suppliesPattern(opt string) bool {
+	if strings.HasPrefix(opt, "--") {
+		name, _, _ := strings.Cut(opt, "=")
+		return slices.Contains(g.patternOptions, name)
+	}
+	for _, letter := range opt[1:] {
+		if short := "-" + string(letter); slices.Contains(g.valueOptions, short) {
+			return slices.Contains(g.patternOptions, short)
+		}
+	}
+	return false
+}
+
+// options returns the names of the options in args, which start with the
+// command itself, up to any "--". Bundles of short flags are split into their
+// members (e.g. "-SW" into "-S" and "-W"), and option values are dropped,
+// whether glued to the option (e.g. "-sHEAD" or "--source=HEAD") or not.
+func (g commandGrammar) options(args []string) []string {
+	var opts []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return opts
+		case !isOption(arg):
+			continue
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg, "=")
+			opts = append(opts, name)
+		default:
+			for _, letter := range arg[1:] {
+				short := "-" + string(letter)
+				opts = append(opts, short)
+				if slices.Contains(g.valueOptions, short) {
+					break // the rest is its glued value
+				}
+			}
+		}
+		if g.takesValue(arg) {
+			i++
+		}
+	}
+	return opts
+}
+
+// firstPositional returns the index of the first positional argument in args,
+// which start with the command itself, or -1 if there is none before any "--".
+func (g commandGrammar) firstPositional(args []string) int {
+	for i := 1; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			return -1
+		case isOption(arg):
+			if g.takesValue(arg) {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// expansion returns how a positional argument in slot s is to be expanded.
+func (s slot) expansion() expansion {
+	switch s {
+	case slotPath, slotEither:
+		return expandShortcuts
+	case slotRevision:
+		return expandNone
+	case slotObject:
+		return expandObjectPaths
+	}
+	panic("unreachable")
+}
+
+// isOption reports whether arg is a command line option rather than a
+// positional argument. A lone "-" is conventionally a positional (stdin).
+func isOption(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-'
+}
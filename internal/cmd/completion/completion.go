@@ -0,0 +1,66 @@
+package completion
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+//go:embed data/git_completion.bash
+var gitCompletionBash string
+
+//go:embed data/git_completion.zsh
+var gitCompletionZsh string
+
+//go:embed data/git_completion.fish
+var gitCompletionFish string
+
+// NewCompletionCmd creates and returns the completion command
+func NewCompletionCmd() *cobra.Command {
+	completionCmd := &cobra.Command{
+		Use:   "completion <shell>",
+		Short: "Output shell completion script",
+		Long: `
+Outputs the tab completion script for scmpuff in bash, zsh or fish.
+
+Besides completing scmpuff's own commands and flags, the script hooks into the
+completion of git, so that pressing TAB after a numeric shortcut, as in
+'git add 3', completes the file it refers to.
+
+This is included in the output of 'scmpuff init' unless --completions=false is
+given, so does not normally need to be loaded separately.
+    `,
+		Example:   "$ source <(scmpuff completion bash)",
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // silence usage-on-error after args processed
+
+			w := cmd.OutOrStdout()
+			root := cmd.Root()
+			var (
+				err  error
+				hook string // completion of shortcuts in git commands
+			)
+			switch args[0] {
+			case "bash":
+				err = root.GenBashCompletionV2(w, true)
+				hook = gitCompletionBash
+			case "zsh":
+				err = root.GenZshCompletion(w)
+				hook = gitCompletionZsh
+			case "fish":
+				err = root.GenFishCompletion(w, true)
+				hook = gitCompletionFish
+			}
+			if err != nil {
+				return fmt.Errorf("failed to generate completion script: %w", err)
+			}
+			_, err = fmt.Fprint(w, "\n"+hook)
+			return err
+		},
+	}
+
+	return completionCmd
+}
@@ -0,0 +1,51 @@
+package completion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewCompletionCmd(t *testing.T) {
+	tests := []struct {
+		shell    string
+		wantRoot string // from the completion of the root command
+		wantHook string
+	}{
+		{shell: "bash", wantRoot: "__start_scmpuff", wantHook: gitCompletionBash},
+		{shell: "zsh", wantRoot: "#compdef scmpuff", wantHook: gitCompletionZsh},
+		{shell: "fish", wantRoot: "complete -c scmpuff", wantHook: gitCompletionFish},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			root := &cobra.Command{Use: "scmpuff"}
+			root.AddCommand(NewCompletionCmd())
+			root.SetArgs([]string{"completion", tt.shell})
+			var out bytes.Buffer
+			root.SetOut(&out)
+
+			if err := root.Execute(); err != nil {
+				t.Fatalf("completion %s failed: %v", tt.shell, err)
+			}
+			if !strings.Contains(out.String(), tt.wantRoot) {
+				t.Errorf("expected output to contain completion of scmpuff (%q)", tt.wantRoot)
+			}
+			if !strings.HasSuffix(out.String(), tt.wantHook) {
+				t.Errorf("expected output to end with the git completion hook")
+			}
+		})
+	}
+
+	t.Run("unknown shell", func(t *testing.T) {
+		root := &cobra.Command{Use: "scmpuff"}
+		root.AddCommand(NewCompletionCmd())
+		root.SetArgs([]string{"completion", "oil"})
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&bytes.Buffer{})
+		if err := root.Execute(); err == nil {
+			t.Errorf("expected error for unsupported shell")
+		}
+	})
+}
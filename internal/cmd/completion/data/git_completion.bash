@@ -0,0 +1,31 @@
+# shellcheck shell=bash
+# Complete a numeric file shortcut in git commands (e.g. "git add 3<TAB>") with
+# the file it refers to, deferring to the completion of git itself otherwise.
+_scmpuff_complete_git() {
+  local cur=${COMP_WORDS[COMP_CWORD]}
+  if [[ $COMP_CWORD -gt 1 && $cur =~ ^[0-9]+$ ]]; then
+    local num file change
+    while IFS=$'\t' read -r num file change; do
+      if [[ $num == "$cur" ]]; then
+        COMPREPLY=("$(printf '%q ' "$file")")
+        return 0
+      fi
+    done < <(/usr/bin/env scmpuff shortcuts "$cur" 2>/dev/null)
+  fi
+
+  # The completion of git is loaded on demand by bash-completion, replacing
+  # this one, which must then be restored.
+  if ! declare -F __git_wrap__git_main >/dev/null; then
+    if declare -F _comp_load >/dev/null; then
+      _comp_load git
+    elif declare -F _completion_loader >/dev/null; then
+      _completion_loader git
+    fi
+    complete -o bashdefault -o default -o nospace -F _scmpuff_complete_git git
+  fi
+  if declare -F __git_wrap__git_main >/dev/null; then
+    __git_wrap__git_main "$@"
+  fi
+}
+
+complete -o bashdefault -o default -o nospace -F _scmpuff_complete_git git
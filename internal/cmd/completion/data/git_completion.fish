@@ -0,0 +1,20 @@
+# Test whether the current token in a git command is a numeric file shortcut
+# (e.g. "git add 3<TAB>").
+function __scmpuff_git_shortcut_token
+    test (count (commandline -opc)) -gt 1
+    and string match -qr '^[0-9]+$' -- (commandline -ct)
+end
+
+# List the numeric file shortcuts starting with the current token, described by
+# the file each refers to.
+function __scmpuff_complete_git_shortcuts
+    /usr/bin/env scmpuff shortcuts (commandline -ct) 2>/dev/null | while read -l -d \t num file change
+        if test -n "$change"
+            printf '%s\t%s (%s)\n' $num $file $change
+        else
+            printf '%s\t%s\n' $num $file
+        end
+    end
+end
+
+complete -c git -n __scmpuff_git_shortcut_token -f -a '(__scmpuff_complete_git_shortcuts)'
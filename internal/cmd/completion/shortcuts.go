@@ -0,0 +1,98 @@
+package completion
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mroth/scmpuff/internal/arguments"
+	"github.com/mroth/scmpuff/internal/cmd/status"
+	"github.com/mroth/scmpuff/internal/shortcuts"
+	"github.com/spf13/cobra"
+)
+
+// maxShortcuts bounds the number of shortcuts listed, in case of a runaway
+// environment.
+const maxShortcuts = 999
+
+// NewShortcutsCmd creates and returns the shortcuts command, used by the
+// completion scripts to complete numeric shortcuts.
+func NewShortcutsCmd() *cobra.Command {
+	shortcutsCmd := &cobra.Command{
+		Use:   "shortcuts [<number>]",
+		Short: "List numeric shortcuts for completion",
+		Long: `Lists the numeric file shortcuts starting with the given number, one per line
+as the number, path (relative to the working directory) and change, separated
+by tabs.`,
+		Args:   cobra.MaximumNArgs(1),
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // silence usage-on-error after args processed
+
+			var prefix string
+			if len(args) > 0 {
+				prefix = args[0]
+			}
+
+			wd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("fatal: failed to retrieve current working directory: %w", err)
+			}
+
+			// The current status is needed to describe the changes, so it is
+			// shared with the resolver should it need it too.
+			load := sync.OnceValues(status.Load)
+			resolver, err := shortcuts.NewResolver(wd, func() (shortcuts.Set, error) {
+				renderer, err := load()
+				if err != nil {
+					return shortcuts.Set{}, err
+				}
+				return renderer.Shortcuts(), nil
+			})
+			if err != nil {
+				return err
+			}
+			change := func(path string) string {
+				renderer, err := load()
+				if err != nil {
+					return ""
+				}
+				msg, _ := renderer.Change(path)
+				return msg
+			}
+
+			return listShortcuts(cmd.OutOrStdout(), prefix, wd, resolver.Lookup, change)
+		},
+	}
+
+	return shortcutsCmd
+}
+
+// listShortcuts writes the shortcuts starting with prefix, with their paths
+// made relative to wd and the change to each as given by change.
+//
+// Paths which cannot be written on a single line are left out.
+func listShortcuts(w io.Writer, prefix, wd string, lookup arguments.Lookup, change func(path string) string) error {
+	for n := 1; n <= maxShortcuts; n++ {
+		path, ok := lookup(shortcuts.VarName(n))
+		if !ok {
+			break
+		}
+		num := strconv.Itoa(n)
+		if !strings.HasPrefix(num, prefix) || strings.ContainsAny(path, "\t\n") {
+			continue
+		}
+		file := path
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			file = rel
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", num, file, change(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
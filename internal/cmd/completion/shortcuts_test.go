@@ -0,0 +1,71 @@
+package completion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mroth/scmpuff/internal/shortcuts"
+)
+
+func TestListShortcuts(t *testing.T) {
+	t.Setenv(shortcuts.PrefixEnvVar, "")
+
+	paths := []string{
+		"/repo/a.txt",
+		"/repo/src/b.go",
+		"/other/c.txt",
+		"/repo/tab\there.txt",
+		"/repo/d.txt", "/repo/e.txt", "/repo/f.txt", "/repo/g.txt", "/repo/h.txt",
+		"/repo/ten.txt",
+		"/repo/eleven.txt",
+	}
+	lookup := func(name string) (string, bool) {
+		n, ok := shortcuts.ParseVarName(name)
+		if !ok || n > len(paths) {
+			return "", false
+		}
+		return paths[n-1], true
+	}
+	change := func(path string) string {
+		if path == "/repo/a.txt" {
+			return "modified"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "2", want: []string{"2\tsrc/b.go\t"}},
+		{prefix: "1", want: []string{"1\ta.txt\tmodified", "10\tten.txt\t", "11\televen.txt\t"}},
+		{prefix: "3", want: []string{"3\t../other/c.txt\t"}},
+		{prefix: "4", want: nil},  // path cannot be listed
+		{prefix: "12", want: nil}, // no such shortcut
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			var b strings.Builder
+			if err := listShortcuts(&b, tt.prefix, "/repo", lookup, change); err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			if b.Len() == 0 {
+				got = nil
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("listShortcuts(%q) = %q, want %q", tt.prefix, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("all", func(t *testing.T) {
+		var b strings.Builder
+		if err := listShortcuts(&b, "", "/repo", lookup, change); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Count(b.String(), "\n"), len(paths)-1; got != want {
+			t.Errorf("listed %d shortcuts, want %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package configs
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mroth/scmpuff/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCmd creates and returns the config command
+func NewConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Get and set scmpuff settings",
+		Long: `Gets and sets scmpuff settings.
+
+Settings are read from the following places, with those earlier in the list
+taking precedence:
+
+  1. command line flags
+  2. environment variables, for the settings that have one
+  3. repository git config (.git/config), under the "scmpuff" section
+  4. the user config file, $XDG_CONFIG_HOME/scmpuff/config.toml
+     (~/.config/scmpuff/config.toml by default)
+  5. global git config (~/.gitconfig), under the "scmpuff" section
+  6. built-in defaults
+
+In git config, a setting such as init.prefix is the key scmpuff.init.prefix.
+In the config file, it is the prefix key of the [init] table:
+
+    [init]
+    prefix = "f"
+
+Options that are never expanded as file shortcuts for a git subcommand are
+configured as noexpand.<subcommand> (e.g. noexpand.log = "--author -L"), and
+accumulate across all of these places.
+
+Shell aliases defined by 'scmpuff init' are configured as alias.<name> (e.g.
+alias.gdc = "git diff --cached"), where an empty command removes a default
+alias.
+
+How the git wrapper runs a subcommand is configured as wrapper.<subcommand>:
+"off" to run git directly, or "absolute" or "relative" to expand file shortcuts
+to absolute or relative paths, optionally followed by "refresh" to show the
+status afterward (e.g. wrapper.stash = "relative refresh"), "refresh=summary"
+to show a one line summary of it, or "refresh=quiet" to only update the file
+shortcuts.`,
+		Args: cobra.NoArgs,
+	}
+
+	configCmd.AddCommand(newListCmd(), newGetCmd(), newSetCmd())
+	return configCmd
+}
+
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the effective value of all settings and where they came from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // silence usage-on-error after args processed
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			values, err := cfg.List()
+			if err != nil {
+				return err
+			}
+			return listValues(cmd.OutOrStdout(), values)
+		},
+	}
+}
+
+// listValues writes a table of values and their locations to w.
+func listValues(w io.Writer, values []config.Value) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, v := range values {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Key, v.Value, v.Location())
+	}
+	return tw.Flush()
+}
+
+func newGetCmd() *cobra.Command {
+	var showOrigin bool
+
+	getCmd := &cobra.Command{
+		Use:               "get <key>",
+		Short:             "Print the effective value of a setting",
+		Example:           "$ scmpuff config get --show-origin init.prefix",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeKeyValue,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // silence usage-on-error after args processed
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			v, err := cfg.Get(args[0])
+			if err != nil {
+				return err
+			}
+			if showOrigin {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", v.Location(), v.Value)
+			} else {
+				fmt.Fprintln(cmd.OutOrStdout(), v.Value)
+			}
+			return nil
+		},
+	}
+
+	getCmd.Flags().BoolVar(&showOrigin, "show-origin", false, "show where the value came from")
+	return getCmd
+}
+
+func newSetCmd() *cobra.Command {
+	var repo bool
+
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting in the user config file or repository",
+		Long: `Sets a setting in the user config file, or with --repo in the git config of
+the current repository.
+
+The value is validated before it is written. Settings given by a flag or an
+environment variable still take precedence over the value set here. Values
+beginning with a dash must follow a "--" argument.`,
+		Example: `$ scmpuff config set init.prefix f
+$ scmpuff config set --repo confirmThreshold 20
+$ scmpuff config set -- noexpand.log "--author -L"`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeKeyValue,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // silence usage-on-error after args processed
+
+			key, value := args[0], args[1]
+			if repo {
+				return config.SetRepo(key, value)
+			}
+			_, err := config.SetUser(key, value)
+			return err
+		},
+	}
+
+	setCmd.Flags().BoolVar(&repo, "repo", false, "set in the git config of the current repository")
+	return setCmd
+}
+
+// completeKeyValue completes the key of a setting as the first argument, and
+// its value as the second, where the setting has a fixed set of values.
+func completeKeyValue(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		var keys []cobra.Completion
+		for _, s := range config.Settings {
+			keys = append(keys, cobra.CompletionWithDesc(s.Key, s.Usage))
+		}
+		return keys, cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		if cmd.Name() != "set" {
+			break
+		}
+		i := slices.IndexFunc(config.Settings, func(s config.Setting) bool { return strings.EqualFold(s.Key, args[0]) })
+		if i == -1 {
+			break
+		}
+		switch s := config.Settings[i]; {
+		case len(s.Choices) > 0:
+			return s.Choices, cobra.ShellCompDirectiveNoFileComp
+		case s.Kind == config.KindBool:
+			return []cobra.Completion{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
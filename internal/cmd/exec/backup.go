@@ -0,0 +1,50 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mroth/scmpuff/internal/snapshot"
+)
+
+// backupDestructive snapshots the files targeted by a destructive git command
+// before it runs, so that discarded changes can be recovered with "scmpuff
+// undo", given the directory it runs in, and the original input args as well as
+// their symbolic and evaluated forms.
+//
+// Failing to take a snapshot is reported as a warning rather than an error,
+// as it should not prevent the user from running the command.
+func backupDestructive(dir string, inputArgs, symbolicArgs, evaluatedArgs []string) {
+	if !isDestructive(symbolicArgs, os.Getenv("SCMPUFF_GIT_CMD")) {
+		return
+	}
+	files := targetedFiles(symbolicArgs, evaluatedArgs)
+	if len(files) == 0 {
+		return
+	}
+
+	// describe the command as the user typed it, e.g. "git checkout 1-3"
+	command := "git " + strings.Join(inputArgs[1:], " ")
+	if err := backup(dir, command, files); err != nil {
+		fmt.Fprintf(os.Stderr, "scmpuff: warning: failed to back up files before %s: %v\n", command, err)
+	}
+}
+
+func backup(dir string, command string, files []string) error {
+	journal, err := snapshot.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	absPaths := make([]string, len(files))
+	for i, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(dir, f)
+		}
+		absPaths[i] = f
+	}
+	_, err = journal.Save(command, absPaths)
+	return err
+}
@@ -0,0 +1,103 @@
+package exec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mroth/scmpuff/internal/arguments"
+	"github.com/mroth/scmpuff/internal/config"
+	"github.com/mroth/scmpuff/internal/gitstatus"
+)
+
+// confirmDestructive asks the user to confirm running a destructive git command
+// that targets more file shortcuts than the threshold configured in cfg, given
+// the symbolic and evaluated forms of its args, and reports whether to proceed.
+//
+// Confirmation is only requested when stdin is a terminal, so that scripts and
+// other non-interactive usage are never blocked.
+func confirmDestructive(cfg *config.Config, symbolicArgs, evaluatedArgs []string) (bool, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) || !isDestructive(symbolicArgs, os.Getenv("SCMPUFF_GIT_CMD")) {
+		return true, nil
+	}
+
+	threshold, err := cfg.Int("confirmThreshold")
+	if err != nil {
+		return false, err
+	}
+	files := targetedFiles(symbolicArgs, evaluatedArgs)
+	if threshold < 0 || len(files) <= threshold {
+		return true, nil
+	}
+
+	command := "git " + arguments.ParseGitCommand(symbolicArgs[1:]).Subcommand()
+	return confirm(os.Stdin, os.Stderr, command, files)
+}
+
+// isDestructive reports whether the symbolically expanded args are a git
+// command that discards uncommitted work in the files it targets, such as
+// "git checkout <path>" or "git rm <path>".
+//
+// gitCmd is the value of SCMPUFF_GIT_CMD; only commands where args[0] matches
+// it are considered to be git commands.
+func isDestructive(args []string, gitCmd string) bool {
+	if len(args) < 2 || gitCmd == "" || args[0] != gitCmd {
+		return false
+	}
+	git := arguments.ParseGitCommand(args[1:])
+	if len(git.Args) == 0 {
+		return false
+	}
+
+	switch git.Subcommand() {
+	case "checkout":
+		return true
+	case "clean":
+		return !git.HasOption("-n", "--dry-run")
+	case "restore":
+		// --staged alone only touches the index, --worktree is the default
+		return !git.HasOption("-S", "--staged") || git.HasOption("-W", "--worktree")
+	case "rm":
+		return !git.HasOption("--cached", "-n", "--dry-run")
+	}
+	// NOTE: "reset --hard" discards changes too, but takes no paths, so there
+	// are never any file shortcuts to confirm or snapshot.
+	return false
+}
+
+// targetedFiles returns the evaluated paths of all args that were expanded from
+// file shortcuts, given the symbolic and evaluated forms of the same args.
+func targetedFiles(symbolicArgs, evaluatedArgs []string) []string {
+	var files []string
+	for i, arg := range symbolicArgs {
+		if arguments.IsShortcut(arg) {
+			files = append(files, evaluatedArgs[i])
+		}
+	}
+	return files
+}
+
+// confirm lists the files affected by a destructive command to w and asks the
+// user for confirmation, reading the answer from r. Anything other than an
+// explicit yes is treated as a no.
+func confirm(r io.Reader, w io.Writer, command string, files []string) (bool, error) {
+	fmt.Fprintf(w, "%s will discard changes to %d files:\n", command, len(files))
+	for _, f := range files {
+		fmt.Fprintf(w, "  %s\n", gitstatus.QuotePath(f, gitstatus.QuoteUnprintable))
+	}
+	fmt.Fprint(w, "Continue? [y/N] ")
+
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -1,14 +1,33 @@
 package exec
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/fatih/color"
 	"github.com/mroth/scmpuff/internal/arguments"
+	"github.com/mroth/scmpuff/internal/cmd/status"
+	"github.com/mroth/scmpuff/internal/config"
+	"github.com/mroth/scmpuff/internal/gitstatus"
+	"github.com/mroth/scmpuff/internal/shortcuts"
 	"github.com/spf13/cobra"
 )
 
 var expandRelative bool
+var dryRun bool
+var assumeYes bool
+
+// dryRunEnvVar is the environment variable that enables --dry-run by default,
+// so that it can be toggled for commands invoked via the shell git wrapper.
+const dryRunEnvVar = "SCMPUFF_DRY_RUN"
 
 // NewExecCmd creates and returns the exec command
 func NewExecCmd() *cobra.Command {
@@ -20,45 +39,210 @@ func NewExecCmd() *cobra.Command {
 		Short:   "Execute cmd with numeric shortcuts",
 		Long: `Expands numeric shortcuts to their full filepath and executes the command.
 
-Takes a list of digits (1 4 5) or numeric ranges (1-5) or even both.`,
+Takes a list of digits (1 4 5) or numeric ranges (1-5) or even both.
+
+Git commands that discard uncommitted changes in the files they target
+(checkout, restore, rm, clean) ask for confirmation when targeting more file
+shortcuts than the confirmThreshold setting (default 5, or -1 to disable; see
+'scmpuff config'). This only happens when stdin is a terminal, and can be
+skipped with --yes.
+
+The files targeted by these commands are also snapshotted before running them,
+so discarded changes can be recovered with 'scmpuff undo'.
+
+When the numbered file list has changed since the shortcuts were set by
+scmpuff_status, a warning is printed for shortcuts referring to files that no
+longer appear in git status. Shortcuts are also scoped to the repository they
+were set in: after changing to another repository, the shortcuts last shown by
+scmpuff_status for that repository are used instead, or refused if there are
+none.
+
+With --dry-run, the expanded command is printed instead of being executed. This
+can also be enabled by setting ` + dryRunEnvVar + `=1 in the environment, which
+applies to commands run via the shell git wrapper as well, or with the
+exec.dryRun setting.`,
 		RunE: func(cmd *cobra.Command, inputArgs []string) error {
 			cmd.SilenceUsage = true // silence usage-on-error after args processed
 
-			expandedArgs := Process(inputArgs)
-			a := expandedArgs[1:]
-			subcmd := exec.Command(expandedArgs[0], a...)
-			subcmd.Stdin = os.Stdin
-			subcmd.Stdout = os.Stdout
-			subcmd.Stderr = os.Stderr
-
-			err := subcmd.Run()
+			cfg, err := config.Load()
 			if err != nil {
-				// process exited with a non-zero exit code, we want to just exit
-				// directly with that code rather than returning control back to cobra.
-				if exitError, ok := err.(*exec.ExitError); ok {
-					os.Exit(exitError.ExitCode())
+				return err
+			}
+			if !cmd.Flags().Changed("dry-run") {
+				if dryRun, err = cfg.Bool("exec.dryRun"); err != nil {
+					return err
 				}
+			}
 
-				// otherwise, we failed to start execution, return error to cobra
+			inputArgs = ResolveGitAlias(inputArgs)
+			code, err := Run(cfg, inputArgs, Options{Relative: expandRelative, DryRun: dryRun, AssumeYes: assumeYes})
+			if err != nil {
 				return err
 			}
-
-			// normal case: exec completed successfully.
+			// the command exited with a non-zero exit code, we want to just exit
+			// directly with that code rather than returning control back to cobra.
+			if code != 0 {
+				os.Exit(code)
+			}
 			return nil
 		},
 	}
 
 	execCmd.Flags().BoolVarP(&expandRelative, "relative", "r", false, "make path relative to current working directory")
+	execCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the expanded command instead of executing it")
+	execCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "skip confirmation for destructive commands")
 	return execCmd
 }
 
-// Process expands args and performs all substitution, then returns the argument array
-func Process(args []string) []string {
+// Options control how Run expands and executes a command.
+type Options struct {
+	Relative  bool // expand shortcuts to paths relative to the working directory
+	DryRun    bool // print the expanded command instead of executing it
+	AssumeYes bool // skip confirmation for destructive commands
+}
+
+// Run expands the numeric shortcuts in args, which start with the command to
+// execute, and executes it connected to the standard streams of this process.
+//
+// It returns the exit code of the command (or 1 if the user declined to run
+// it), and an error only if it could not be run at all.
+func Run(cfg *config.Config, args []string, opts Options) (int, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return 0, fmt.Errorf("fatal: failed to retrieve current working directory: %w", err)
+	}
+	resolver, err := shortcuts.NewResolver(wd, status.CurrentShortcuts)
+	if err != nil {
+		return 0, err
+	}
+
+	symbolicArgs := arguments.Expand(args, cfg.NoExpandRules())
+
+	// Guard against using shortcuts that no longer match the status.
+	checkShortcuts(os.Stderr, symbolicArgs, os.Getenv("SCMPUFF_GIT_CMD"), resolver)
+
+	// Relative paths must be relative to where the command runs, which may be
+	// changed by git's -C option.
+	dir := arguments.WorkingDir(args, os.Getenv("SCMPUFF_GIT_CMD"), wd)
+	var relativeTo string
+	if opts.Relative {
+		relativeTo = dir
+	}
+
+	if opts.DryRun {
+		return 0, printDryRun(os.Stdout, symbolicArgs, dir, relativeTo, resolver.Lookup)
+	}
+
+	expandedArgs := evaluate(symbolicArgs, dir, relativeTo, resolver.Lookup)
+
+	// Guard against accidentally discarding work in many files at once.
+	if !opts.AssumeYes {
+		ok, err := confirmDestructive(cfg, symbolicArgs, expandedArgs)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Aborted.")
+			return 1, nil
+		}
+	}
+	backupDestructive(dir, args, symbolicArgs, expandedArgs)
+
+	subcmd := exec.Command(expandedArgs[0], expandedArgs[1:]...)
+	subcmd.Stdin = os.Stdin
+	subcmd.Stdout = os.Stdout
+	subcmd.Stderr = os.Stderr
+	return ExitCode(subcmd.Run())
+}
+
+// ResolveGitAlias returns args, which start with the command to execute, with
+// a git alias used as the subcommand of a git command resolved to the command
+// line it stands for (e.g. "git co 3" becomes "git checkout 3"), so that its
+// arguments are expanded as for the actual subcommand. Any other command is
+// returned as is.
+//
+// Only commands where args[0] matches SCMPUFF_GIT_CMD are git commands.
+func ResolveGitAlias(args []string) []string {
+	gitCmd := os.Getenv("SCMPUFF_GIT_CMD")
+	if len(args) < 2 || gitCmd == "" || args[0] != gitCmd {
+		return args
+	}
+	git := arguments.ParseGitCommand(args[1:])
+	git = git.ResolveAlias(func(name string) (string, bool) {
+		return config.GitAlias(git.Globals, name)
+	})
+	return slices.Concat(args[:1], git.Globals, git.Args)
+}
+
+// ExitCode returns the exit code of a command that finished running with err,
+// or err itself if the command failed to start.
+func ExitCode(err error) (int, error) {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// evaluate performs environment substitution on the symbolically expanded args
+// of a command running in dir, making paths relative to the relativeTo
+// directory if not empty (see arguments.EvaluateEnvironment).
+func evaluate(symbolicArgs []string, dir, relativeTo string, lookup arguments.Lookup) []string {
 	var processedArgs []string
-	for _, arg := range arguments.Expand(args) {
-		processed := arguments.EvaluateEnvironment(arg, expandRelative)
+	for _, arg := range symbolicArgs {
+		processed := arguments.EvaluateEnvironment(arg, dir, relativeTo, lookup)
 		processedArgs = append(processedArgs, processed)
 	}
 
 	return processedArgs
 }
+
+var unresolvedColor = color.New(color.FgRed, color.Bold)
+
+// printDryRun writes the command that would be executed for the symbolically
+// expanded args to w, with one shell-quoted argument per line (joined by line
+// continuations so that it may be pasted back into a shell).
+//
+// Position variables that could not be resolved are printed as the (quoted)
+// variable reference itself, highlighted, and reported in a trailing warning.
+func printDryRun(w io.Writer, expandedArgs []string, dir, relativeTo string, lookup arguments.Lookup) error {
+	var lines, unresolved []string
+	for _, arg := range expandedArgs {
+		if arguments.IsUnresolved(arg, lookup) {
+			lines = append(lines, unresolvedColor.Sprint(`"`+arg+`"`))
+			unresolved = append(unresolved, arg)
+			continue
+		}
+		lines = append(lines, shellQuote(arguments.EvaluateEnvironment(arg, dir, relativeTo, lookup)))
+	}
+
+	if _, err := fmt.Fprintln(w, strings.Join(lines, " \\\n  ")); err != nil {
+		return err
+	}
+	if len(unresolved) > 0 {
+		_, err := fmt.Fprintf(w, "# warning: unresolved shortcuts (not assigned by scmpuff status): %s\n",
+			strings.Join(unresolved, " "))
+		return err
+	}
+	return nil
+}
+
+// shellSafe matches arguments that need no quoting for a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes arg for a POSIX shell, using single quotes when necessary.
+//
+// An argument with control or other non-printable characters, which must not
+// reach the terminal and would not survive being pasted, is quoted ANSI-C style
+// instead (e.g. $'a\nb'), as understood by bash, zsh and ksh.
+func shellQuote(arg string) string {
+	if shellSafe.MatchString(arg) {
+		return arg
+	}
+	if utf8.ValidString(arg) && !strings.ContainsFunc(arg, func(r rune) bool { return !unicode.IsPrint(r) }) {
+		return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	// the C-style escapes of git are valid within $'...' as well
+	quoted := gitstatus.QuotePath(arg, gitstatus.QuoteUnprintable)
+	return "$'" + strings.ReplaceAll(quoted[1:len(quoted)-1], "'", `\'`) + "'"
+}
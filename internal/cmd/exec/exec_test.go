@@ -0,0 +1,132 @@
+package exec
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg, want string
+	}{
+		{arg: "src/main.go", want: "src/main.go"},
+		{arg: "hi mom.txt", want: "'hi mom.txt'"},
+		{arg: "it's.txt", want: `'it'\''s.txt'`},
+		{arg: "", want: "''"},
+		{arg: "a\nb.txt", want: `$'a\nb.txt'`},
+		{arg: "\x1b]0;title\a.txt", want: `$'\033]0;title\a.txt'`},
+		{arg: "it's\t\"x\".txt", want: `$'it\'s\t\"x\".txt'`},
+		{arg: "caf\xe9.txt", want: `$'caf\351.txt'`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.arg); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func Test_printDryRun(t *testing.T) {
+	color.NoColor = true
+	t.Setenv("e1", "/repo/a b.txt")
+	t.Setenv("e2", "")
+	t.Setenv("e3", "/repo/\x1b[2Jnew\nline.txt")
+
+	var buf bytes.Buffer
+	if err := printDryRun(&buf, []string{"git", "add", "$e1", "$e3", "$e2"}, "", "", os.LookupEnv); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `git \
+  add \
+  '/repo/a b.txt' \
+  $'/repo/\033[2Jnew\nline.txt' \
+  "$e2"
+# warning: unresolved shortcuts (not assigned by scmpuff status): $e2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("printDryRun() got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func Test_isDestructive(t *testing.T) {
+	tests := []struct {
+		args string
+		want bool
+	}{
+		{"git checkout $e1", true},
+		{"git restore $e1", true},
+		{"git restore --staged $e1", false},
+		{"git restore --staged --worktree $e1", true},
+		{"git restore -SW $e1", true},
+		{"git restore -Ss HEAD $e1", false},
+		{"git restore -sHEAD -S $e1", false},
+		{"git restore -s -S $e1", true}, // "-S" is the source
+		{"git restore -- -S $e1", true}, // "-S" is a path
+		{"git rm $e1", true},
+		{"git rm --cached $e1", false},
+		{"git rm -rn $e1", false},
+		{"git clean -f $e1", true},
+		{"git clean -n $e1", false},
+		{"git clean -fdn $e1", false},
+		{"git clean --dry-run $e1", false},
+		{"git clean -e -n $e1", true}, // "-n" is the exclude pattern
+		{"git reset --hard", false}, // takes no paths
+		{"git reset $e1", false},
+		{"git add $e1", false},
+		{"git -C ../other checkout $e1", true},
+		{"git -c core.pager=cat rm --cached $e1", false},
+		{"git -C checkout add $e1", false}, // "checkout" is the -C directory
+		{"rm $e1", false},                  // not a git command
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			if got := isDestructive(strings.Split(tt.args, " "), "git"); got != tt.want {
+				t.Errorf("isDestructive(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_confirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "YES\n", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false}, // EOF
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := confirm(strings.NewReader(tt.input), &out, "git checkout", []string{"a.txt", "b.txt"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			if !strings.Contains(out.String(), "  b.txt\n") {
+				t.Errorf("confirm prompt did not list targeted files:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func Test_staleShortcuts(t *testing.T) {
+	t.Setenv("e1", "/repo/a.txt")
+	t.Setenv("e2", "/repo/gone.txt")
+	t.Setenv("e3", "/repo/b.txt")
+
+	got := staleShortcuts([]string{"e1", "e2", "e3", "e9"}, []string{"/repo/b.txt", "/repo/a.txt"}, os.LookupEnv)
+	if want := []string{"e2"}; !slices.Equal(got, want) {
+		t.Errorf("staleShortcuts() = %v, want %v", got, want)
+	}
+}
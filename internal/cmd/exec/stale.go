@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/mroth/scmpuff/internal/arguments"
+	"github.com/mroth/scmpuff/internal/cmd/status"
+	"github.com/mroth/scmpuff/internal/gitstatus"
+	"github.com/mroth/scmpuff/internal/shortcuts"
+)
+
+// checkShortcuts verifies that the file shortcuts referenced by a git command's
+// symbolically expanded args still match the current git status, writing a
+// warning to w for any that refer to files no longer appearing in it, as the
+// numbering the user saw is likely out of date.
+//
+// Nothing is checked when the fingerprint of the shortcuts is unknown (e.g.
+// older shell functions, or shortcuts set by hand), or when not within a git
+// repository.
+func checkShortcuts(w io.Writer, symbolicArgs []string, gitCmd string, resolver *shortcuts.Resolver) {
+	if len(symbolicArgs) < 2 || gitCmd == "" || symbolicArgs[0] != gitCmd {
+		return
+	}
+	var names []string
+	for _, arg := range symbolicArgs {
+		if name, ok := arguments.ShortcutVar(arg); ok {
+			names = append(names, name)
+		}
+	}
+	fingerprint, ok := resolver.Fingerprint()
+	if len(names) == 0 || !ok {
+		return
+	}
+
+	current, err := status.Load()
+	if err != nil || current.Fingerprint() == fingerprint {
+		return // on error, git will report any problem itself
+	}
+
+	for _, name := range staleShortcuts(names, current.ShortcutPaths(), resolver.Lookup) {
+		path, _ := resolver.Lookup(name)
+		fmt.Fprintf(w, "warning: $%s (%s) no longer appears in git status, run scmpuff_status to refresh shortcuts\n",
+			name, gitstatus.QuotePath(path, gitstatus.QuoteUnprintable))
+	}
+}
+
+// staleShortcuts returns the names of the shortcut variables whose path is not
+// among the current shortcut paths.
+func staleShortcuts(names, paths []string, lookup arguments.Lookup) []string {
+	var stale []string
+	for _, name := range names {
+		if path, _ := lookup(name); path != "" && !slices.Contains(paths, path) {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
@@ -2,33 +2,72 @@ package expand
 
 import (
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 
 	"github.com/mroth/scmpuff/internal/arguments"
+	"github.com/mroth/scmpuff/internal/cmd/status"
+	"github.com/mroth/scmpuff/internal/config"
+	"github.com/mroth/scmpuff/internal/shortcuts"
 	"github.com/spf13/cobra"
 )
 
 // NewExpandCmd creates and returns the expand command
 func NewExpandCmd() *cobra.Command {
-	var expandRelative bool
+	var (
+		expandRelative bool
+		expandNul      bool
+	)
 
 	expandCmd := &cobra.Command{
 		Use:   "expand [flags] <shortcuts...>",
 		Short: "Expands numeric shortcuts",
 		Long: `Expands numeric shortcuts to their full filepath.
 
-Takes a list of digits (1 4 5) or numeric ranges (1-5) or even both.`,
+Takes a list of digits (1 4 5) or numeric ranges (1-5) or even both.
+
+The paths are escaped for the shell and separated by tabs, or with -z, printed
+as they are and terminated by NUL characters, for callers quoting them
+themselves (such as the key binding of 'scmpuff init --expand-key').`,
 		Example: "$ scmpuff expand 1-2\n/tmp/foo.txt    /tmp/bar.txt",
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true // silence usage-on-error after args processed
-			fmt.Print(Process(args, expandRelative))
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			rules := cfg.NoExpandRules()
+
+			wd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("fatal: failed to retrieve current working directory: %w", err)
+			}
+			resolver, err := shortcuts.NewResolver(wd, status.CurrentShortcuts)
+			if err != nil {
+				return err
+			}
+
+			dir := arguments.WorkingDir(args, os.Getenv("SCMPUFF_GIT_CMD"), wd)
+			var relativeTo string
+			if expandRelative {
+				relativeTo = dir
+			}
+			if expandNul {
+				for _, path := range Expand(args, dir, relativeTo, rules, resolver.Lookup) {
+					fmt.Print(path + "\x00")
+				}
+				return nil
+			}
+			fmt.Print(Process(args, dir, relativeTo, rules, resolver.Lookup))
 			return nil
 		},
 	}
 
 	expandCmd.Flags().BoolVarP(&expandRelative, "relative", "r", false, "make path relative to current working directory")
+	expandCmd.Flags().BoolVarP(&expandNul, "null", "z", false, "print unescaped paths terminated by NUL characters")
 	return expandCmd
 }
 
@@ -36,11 +75,13 @@ var shellEscaper = regexp.MustCompile(`([\\()\[\]<>' ";|*^])`)
 
 // Process expands args and performs all substitution, etc.
 //
-// Ends up with a final string that is TAB delineated between arguments.
-func Process(args []string, relative bool) string {
+// Ends up with a final string that is TAB delineated between arguments, with
+// paths made relative to the relativeTo directory if not empty. dir is the
+// directory the command runs in (see arguments.EvaluateEnvironment).
+func Process(args []string, dir, relativeTo string, rules arguments.NoExpandRules, lookup arguments.Lookup) string {
 	var processedArgs []string
-	for _, arg := range arguments.Expand(args) {
-		processed := escape(arguments.EvaluateEnvironment(arg, relative))
+	for _, arg := range Expand(args, dir, relativeTo, rules, lookup) {
+		processed := escape(arg)
 
 		// if we still ended up with a totally blank arg, escape it here.
 		// we handle this as a special case rather than in expandArg because we
@@ -55,6 +96,15 @@ func Process(args []string, relative bool) string {
 	return strings.Join(processedArgs, "\t")
 }
 
+// Expand expands args as Process does, without escaping the results.
+func Expand(args []string, dir, relativeTo string, rules arguments.NoExpandRules, lookup arguments.Lookup) []string {
+	var expanded []string
+	for _, arg := range arguments.Expand(args, rules) {
+		expanded = append(expanded, arguments.EvaluateEnvironment(arg, dir, relativeTo, lookup))
+	}
+	return expanded
+}
+
 // Escape everything so it can be interpreted once passed along to the shell.
 func escape(arg string) string {
 	return shellEscaper.ReplaceAllString(arg, "\\$1")
@@ -1,13 +1,15 @@
 package expand
 
 import (
+	"os"
+	"slices"
 	"testing"
 )
 
 // Process expansion with an empty arg should be quoted so it doesnt get lost,
 // special case handling that occurs in final step (to avoid escaping).
 func TestProcessEmpty(t *testing.T) {
-	actual := Process([]string{"a", "", "c"}, false)
+	actual := Process([]string{"a", "", "c"}, "", "", nil, os.LookupEnv)
 	expected := "a\t''\tc"
 
 	if actual != expected {
@@ -33,10 +35,20 @@ func TestProcessEscaping(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Setenv("e1", tt.value)
-			actual := Process([]string{"1"}, false)
+			actual := Process([]string{"1"}, "", "", nil, os.LookupEnv)
 			if actual != tt.want {
 				t.Errorf("Process([1])=%q, want %q", actual, tt.want)
 			}
 		})
 	}
 }
+
+func TestExpand(t *testing.T) {
+	t.Setenv("e1", "a`id`$HOME&b\tc.txt")
+	t.Setenv("e2", "")
+	got := Expand([]string{"1", "2", "x y"}, "", "", nil, os.LookupEnv)
+	want := []string{"a`id`$HOME&b\tc.txt", "", "x y"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expand() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/mroth/scmpuff/internal/arguments"
+	execcmd "github.com/mroth/scmpuff/internal/cmd/exec"
+	"github.com/mroth/scmpuff/internal/cmd/status"
+	"github.com/mroth/scmpuff/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// NewGitCmd creates and returns the git command
+func NewGitCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "git [--filelist-out=<path>] [--] <git args...>",
+		Short: "Run git, expanding numeric shortcuts as the shell wrapper does",
+		Long: `Runs git, expanding numeric shortcuts for the subcommands configured in the
+git wrapper dispatch table. This is what the git() shell function installed by
+'scmpuff init' calls for every git command.
+
+For each subcommand, the wrapper.<subcommand> setting determines whether file
+shortcuts are expanded to absolute or relative paths, or git is run directly,
+and whether the status is refreshed afterward (see 'scmpuff config'), which it
+never is after a dry run. If the configuration is invalid, a warning is printed
+and git is run directly. Frequently used subcommands that take no files, such as
+push, fetch or status, are always run directly, without loading the
+configuration. The real git binary is $SCMPUFF_GIT_CMD, or git from the PATH if
+unset.
+
+When the status is refreshed, the numbered files are written to the file given
+by --filelist-out, so that the shell function can assign them to shortcut
+variables, as with 'scmpuff status'. The exit code is always that of git.
+
+All other arguments are passed to git, so this must be the first argument.`,
+		Example:            "$ scmpuff git -- add 1-3",
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // silence usage-on-error after args processed
+
+			filelistOut, gitArgs := parseArgs(args)
+			gitCmd, err := gitCommand()
+			if err != nil {
+				return err
+			}
+
+			// Every git command typed in the shell goes through here, so the
+			// most frequent ones which never expand shortcuts are run straight
+			// away, and a broken configuration must not keep git from running.
+			subcommand := arguments.ParseGitCommand(gitArgs).Subcommand()
+			if slices.Contains(config.PassthroughSubcommands, subcommand) {
+				return exitWith(runGit(gitCmd, gitArgs))
+			}
+			cfg, rules, dryRun, err := loadConfig()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "scmpuff: %v (running git without shortcut expansion)\n", err)
+			}
+
+			// A subcommand without a rule may be a git alias of one that has
+			// a rule, which is dispatched and expanded as if typed out.
+			execArgs := append([]string{gitCmd}, gitArgs...)
+			rule, ok := ruleFor(gitArgs, rules)
+			if !ok {
+				execArgs = execcmd.ResolveGitAlias(execArgs)
+				rule, _ = ruleFor(execArgs[1:], rules)
+			}
+
+			var code int
+			if rule.Mode == config.WrapOff {
+				// NOTE: git resolves aliases itself, e.g. for "git co --help"
+				code, err = runGit(gitCmd, gitArgs)
+			} else {
+				opts := execcmd.Options{Relative: rule.Mode == config.WrapRelative, DryRun: dryRun}
+				code, err = execcmd.Run(cfg, execArgs, opts)
+			}
+			if err != nil {
+				return err
+			}
+
+			// after a dry run, nothing changed and a refresh would suggest otherwise
+			if rule.Refresh != config.RefreshNone && !dryRun {
+				if err := status.Refresh(os.Stdout, cfg, filelistOut, rule.Refresh); err != nil {
+					fmt.Fprintf(os.Stderr, "scmpuff: failed to refresh status: %v\n", err)
+				}
+			}
+			return exitWith(code, nil)
+		},
+	}
+}
+
+// runGit runs the git binary gitCmd with args, connected to the standard
+// streams of this process, and returns its exit code.
+func runGit(gitCmd string, args []string) (int, error) {
+	c := exec.Command(gitCmd, args...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return execcmd.ExitCode(c.Run())
+}
+
+// exitWith exits with the exit code of git if it failed, or returns err.
+func exitWith(code int, err error) error {
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		os.Exit(code)
+	}
+	return nil
+}
+
+// loadConfig returns the configuration, along with the wrapper dispatch table
+// and whether to dry run, which are all nil or false if it cannot be loaded.
+func loadConfig() (cfg *config.Config, rules []config.WrapRule, dryRun bool, err error) {
+	cfg, err = config.Load()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	rules, err = cfg.WrapRules()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	dryRun, err = cfg.Bool("exec.dryRun")
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return cfg, rules, dryRun, nil
+}
+
+// parseArgs separates the options of the git command itself, which must come
+// first, from the git command line.
+func parseArgs(args []string) (filelistOut string, gitArgs []string) {
+	for len(args) > 0 {
+		if path, ok := strings.CutPrefix(args[0], "--filelist-out="); ok {
+			filelistOut = path
+			args = args[1:]
+			continue
+		}
+		if args[0] == "--" {
+			args = args[1:]
+		}
+		break
+	}
+	return filelistOut, args
+}
+
+// ruleFor returns the rule of the dispatch table for the git command line
+// args, and whether the subcommand has one; otherwise, the rule is to run git
+// directly. The subcommand follows any global options, e.g. "git -C ../other
+// add 1".
+func ruleFor(args []string, rules []config.WrapRule) (config.WrapRule, bool) {
+	subcommand := arguments.ParseGitCommand(args).Subcommand()
+	i := slices.IndexFunc(rules, func(r config.WrapRule) bool { return r.Subcommand == subcommand })
+	if i == -1 {
+		return config.WrapRule{Subcommand: subcommand}, false
+	}
+	return rules[i], true
+}
+
+// gitCommand returns the real git binary to run, which is $SCMPUFF_GIT_CMD if
+// set by the shell integration. Otherwise, git is found in the PATH, and
+// $SCMPUFF_GIT_CMD is set to it for the benefit of shortcut expansion, which
+// recognizes git commands by it.
+func gitCommand() (string, error) {
+	if gitCmd := os.Getenv("SCMPUFF_GIT_CMD"); gitCmd != "" {
+		return gitCmd, nil
+	}
+	gitCmd, err := exec.LookPath("git")
+	if err != nil {
+		return "", err
+	}
+	return gitCmd, os.Setenv("SCMPUFF_GIT_CMD", gitCmd)
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mroth/scmpuff/internal/config"
+)
+
+func Test_parseArgs(t *testing.T) {
+	tests := []struct {
+		args            []string
+		wantFilelistOut string
+		wantGitArgs     []string
+	}{
+		{args: []string{"--", "add", "1"}, wantGitArgs: []string{"add", "1"}},
+		{args: []string{"--filelist-out=/tmp/f", "--", "add", "--", "1"}, wantFilelistOut: "/tmp/f", wantGitArgs: []string{"add", "--", "1"}},
+		{args: []string{"--filelist-out=/tmp/f", "status"}, wantFilelistOut: "/tmp/f", wantGitArgs: []string{"status"}},
+		{args: []string{"--version"}, wantGitArgs: []string{"--version"}},
+		{args: []string{"--"}, wantGitArgs: []string{}},
+		{args: nil, wantGitArgs: nil},
+	}
+	for _, tt := range tests {
+		filelistOut, gitArgs := parseArgs(tt.args)
+		if filelistOut != tt.wantFilelistOut || !slices.Equal(gitArgs, tt.wantGitArgs) {
+			t.Errorf("parseArgs(%q) = %q, %q, want %q, %q", tt.args, filelistOut, gitArgs, tt.wantFilelistOut, tt.wantGitArgs)
+		}
+	}
+}
+
+func Test_ruleFor(t *testing.T) {
+	rules := []config.WrapRule{
+		{Subcommand: "add", Mode: config.WrapAbsolute, Refresh: config.RefreshStatus},
+		{Subcommand: "diff", Mode: config.WrapRelative},
+	}
+	tests := []struct {
+		args   []string
+		want   config.WrapRule
+		wantOk bool
+	}{
+		{args: []string{"add", "1"}, want: rules[0], wantOk: true},
+		{args: []string{"diff"}, want: rules[1], wantOk: true},
+		{args: []string{"push", "origin"}, want: config.WrapRule{Subcommand: "push"}},
+		{args: []string{"-C", "../other", "add", "1"}, want: rules[0], wantOk: true},
+		{args: []string{"--no-pager", "-c", "core.pager=cat", "diff", "2"}, want: rules[1], wantOk: true},
+		{args: []string{"-C", "add"}, want: config.WrapRule{}},
+		{args: []string{"--version"}, want: config.WrapRule{}},
+		{args: nil, want: config.WrapRule{}},
+	}
+	for _, tt := range tests {
+		if got, ok := ruleFor(tt.args, rules); got != tt.want || ok != tt.wantOk {
+			t.Errorf("ruleFor(%q) = %+v, %v, want %+v, %v", tt.args, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
@@ -1,7 +0,0 @@
-# shellcheck shell=bash
-alias gs='scmpuff_status'
-alias ga='git add'
-alias gd='git diff'
-alias gl='git log'
-alias gco='git checkout'
-alias grs='git reset'
@@ -0,0 +1,2 @@
+# Tab completion for scmpuff, and for file shortcuts in git commands.
+/usr/bin/env scmpuff completion fish | source
@@ -0,0 +1,8 @@
+# Tab completion for scmpuff, and for file shortcuts in git commands.
+if [ -n "$ZSH_VERSION" ]; then
+  if (( $+functions[compdef] )); then
+    eval "$(/usr/bin/env scmpuff completion zsh)"
+  fi
+elif [ -n "$BASH_VERSION" ]; then
+  eval "$(/usr/bin/env scmpuff completion bash)"
+fi
@@ -0,0 +1,11 @@
+# Expand the numeric shortcut or range (e.g. "3" or "2-4") under or before the
+# cursor in the command line into the paths it refers to, relative to the
+# working directory, quoted for fish.
+function __scmpuff_expand_shortcut
+    set -l token (commandline -t)
+    string match -qr '^[0-9]+(-[0-9]+)?$' -- $token; or return
+
+    set -l paths (/usr/bin/env scmpuff expand -r -z -- $token | string split0)
+    test -n "$paths"; or return
+    commandline -rt -- (string join ' ' -- (string escape -- $paths))
+end
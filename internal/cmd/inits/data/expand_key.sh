@@ -0,0 +1,35 @@
+# Print the paths that the numeric shortcut or range $1 (e.g. "3" or "2-4")
+# refers to, relative to the working directory and quoted by the shell, or fail
+# if it is not one.
+_scmpuff_expand_word() {
+  local re='^[0-9]+(-[0-9]+)?$' file quoted paths=
+  [[ $1 =~ $re ]] || return 1
+  while IFS= read -r -d '' file; do
+    if [ -n "$ZSH_VERSION" ]; then
+      quoted=${(q)file}
+    else
+      printf -v quoted '%q' "$file"
+    fi
+    paths+="${paths:+ }$quoted"
+  done < <(/usr/bin/env scmpuff expand -r -z -- "$1")
+  [[ -n $paths && $paths != "''" ]] || return 1
+  printf '%s' "$paths"
+}
+
+# Expand the shortcut under or before the cursor in the command line, for zsh.
+_scmpuff_expand_zle() {
+  local left=${LBUFFER##*[[:space:]]} right=${RBUFFER%%[[:space:]]*} paths
+  paths=$(_scmpuff_expand_word "$left$right") || return 0
+  LBUFFER=${LBUFFER%"$left"}$paths
+  RBUFFER=${RBUFFER#"$right"}
+}
+
+# Expand the shortcut under or before the cursor in the command line, for bash.
+_scmpuff_expand_readline() {
+  local before=${READLINE_LINE:0:READLINE_POINT} after=${READLINE_LINE:READLINE_POINT}
+  local left=${before##*[[:space:]]} right=${after%%[[:space:]]*} paths
+  paths=$(_scmpuff_expand_word "$left$right") || return 0
+  before=${before%"$left"}$paths
+  READLINE_LINE=$before${after#"$right"}
+  READLINE_POINT=${#before}
+}
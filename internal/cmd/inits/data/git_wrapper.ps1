@@ -0,0 +1,6 @@
+# scmpuff git decides which subcommands expand numeric shortcuts, running git
+# itself (found in $env:PATH, or at $env:SCMPUFF_GIT_CMD) for the rest.
+# NOTE: '--' is quoted, as PowerShell would otherwise drop it.
+function git {
+  scmpuff_run git '--' @args
+}
@@ -7,16 +7,7 @@ unset -f git > /dev/null 2>&1
 SCMPUFF_GIT_CMD=${SCMPUFF_GIT_CMD:-"$(\which git)"}
 export SCMPUFF_GIT_CMD
 
+# scmpuff git decides which subcommands expand numeric shortcuts
 function git() {
-  case $1 in
-    commit|blame|log|rebase|merge)
-      scmpuff exec -- "$SCMPUFF_GIT_CMD" "$@";;
-    checkout|diff|difftool|mergetool|rm|reset|restore)
-      scmpuff exec --relative -- "$SCMPUFF_GIT_CMD" "$@";;
-    add)
-      scmpuff exec -- "$SCMPUFF_GIT_CMD" "$@"
-      scmpuff_status;;
-    *)
-      "$SCMPUFF_GIT_CMD" "$@";;
-  esac
+  scmpuff_run git -- "$@"
 }
@@ -1,32 +1,62 @@
 # Based on https://github.com/arbelt/fish-plugin-scmpuff,
 # with fish3 fix https://github.com/arbelt/fish-plugin-scmpuff/pull/3
 function scmpuff_status
-    scmpuff_clear_vars
-    set -lx scmpuff_env_char "e"
-    set -l cmd_output (/usr/bin/env scmpuff status --filelist $argv)
+    scmpuff_run status $argv
+end
+
+# Run a scmpuff command that lists numbered files, such as status, and export
+# numbered env variables for the files it listed, passing along its exit code.
+function scmpuff_run
+    set -l scmpuff_env_char (scmpuff_prefix)
+
+    # The list of files is written to a temporary file using the NUL-delimited
+    # filelist protocol, which is safe for filenames containing any character.
+    set -l filelist (mktemp); or return
+    /usr/bin/env scmpuff $argv[1] --filelist-out=$filelist $argv[2..-1]
     set -l es "$status"
 
-    if test $es -ne 0
+    # if no files were listed, such as after an error, there is nothing to export
+    if not test -s $filelist
+        rm -f $filelist
         return $es
     end
 
-    set -l files (string split \t $cmd_output[1])
+    # NOTE: command substitution does not split on newlines for string split0
+    set -l records (string split0 < $filelist)
+    rm -f $filelist
+    if test "$records[1]" != "scmpuff-filelist-v3"
+        echo "scmpuff_status: unrecognized filelist protocol, please reload your shell" >&2
+        return 1
+    end
+
+    scmpuff_clear_vars
+    set -gx SCMPUFF_FINGERPRINT "$records[2]"
+    set -e records[1..2]
+    set -l files $records
     if test (count $files) -gt 0
         for e in (seq (count $files))
             set -gx "$scmpuff_env_char""$e" "$files[$e]"
         end
     end
-
-    for line in $cmd_output[2..-1]
-        echo $line
-    end
+    return $es
 end
 
 function scmpuff_clear_vars
-    set -l scmpuff_env_char "e"
+    set -l scmpuff_env_char (scmpuff_prefix)
+    set -e SCMPUFF_FINGERPRINT
     set -l scmpuff_env_vars (set -x | awk '{print $1}' | grep -E '^'$scmpuff_env_char'[0-9]+')
 
     for v in $scmpuff_env_vars
         set -e $v
     end
 end
+
+# Prefix of the numbered env variables, e.g. "e" for $e1. A prefix that is not
+# a valid variable name is ignored, as scmpuff itself ignores it.
+function scmpuff_prefix
+    if string match -qr '^[A-Za-z_][A-Za-z0-9_]*$' -- "$SCMPUFF_PREFIX"
+        echo $SCMPUFF_PREFIX
+    else
+        echo e
+    end
+end
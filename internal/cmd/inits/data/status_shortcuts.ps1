@@ -0,0 +1,58 @@
+function scmpuff_status {
+  scmpuff_run status @args
+}
+
+# Run a scmpuff command that lists numbered files, such as status, and set
+# numbered env variables for the files it listed, passing along its exit code
+# as $LASTEXITCODE.
+function scmpuff_run {
+  $command = $args[0]
+  $rest = @($args | Select-Object -Skip 1)
+  $prefix = scmpuff_prefix
+
+  # The command writes the list of files to a temporary file using the
+  # NUL-delimited filelist protocol, which is safe for filenames containing
+  # any character (even tabs and newlines).
+  # (removed in a finally block, so that it is also cleaned up on Ctrl-C)
+  $filelist = [System.IO.Path]::GetTempFileName()
+  try {
+    & scmpuff $command "--filelist-out=$filelist" @rest
+    $es = $LASTEXITCODE
+    $data = [System.IO.File]::ReadAllText($filelist)
+  } finally {
+    Remove-Item -Force $filelist
+  }
+
+  # if no files were listed, such as after an error, there is nothing to set
+  if ($data.Length -eq 0) {
+    $global:LASTEXITCODE = $es
+    return
+  }
+
+  $records = $data.TrimEnd([char]0).Split([char]0)
+  if ($records[0] -ne 'scmpuff-filelist-v3') {
+    Write-Error 'scmpuff_status: unrecognized filelist protocol, please reload your shell'
+    $global:LASTEXITCODE = 1
+    return
+  }
+
+  scmpuff_clear_vars
+  $env:SCMPUFF_FINGERPRINT = $records[1]
+  for ($i = 2; $i -lt $records.Count; $i++) {
+    Set-Item -Path "env:$prefix$($i - 1)" -Value $records[$i]
+  }
+  $global:LASTEXITCODE = $es
+}
+
+# Clear numbered env variables
+function scmpuff_clear_vars {
+  $prefix = scmpuff_prefix
+  Remove-Item -Path env:SCMPUFF_FINGERPRINT -ErrorAction Ignore
+  Get-ChildItem env: | Where-Object { $_.Name -cmatch "^$prefix[0-9]+$" } | Remove-Item
+}
+
+# Prefix of the numbered env variables, e.g. "e" for $e1. A prefix that is not
+# a valid variable name is ignored, as scmpuff itself ignores it.
+function scmpuff_prefix {
+  if ($env:SCMPUFF_PREFIX -cmatch '^[A-Za-z_][A-Za-z0-9_]*$') { $env:SCMPUFF_PREFIX } else { 'e' }
+}
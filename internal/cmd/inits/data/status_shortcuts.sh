@@ -1,50 +1,81 @@
 # shellcheck shell=bash
 scmpuff_status() {
-  local scmpuff_env_char="e"
+  scmpuff_run status "$@"
+}
 
-  # Ensure shwordsplit is on for zsh
-  if [ -n "$ZSH_VERSION" ]; then setopt shwordsplit; fi;
+# Run a scmpuff command that lists numbered files, such as status, and export
+# numbered env variables for the files it listed, passing along its exit code.
+scmpuff_run() {
+  # A prefix that is not a valid variable name is ignored, as scmpuff itself
+  # ignores it.
+  local scmpuff_env_char=e
+  if [[ $SCMPUFF_PREFIX =~ ^[A-Za-z_][A-Za-z0-9_]*$ ]]; then
+    scmpuff_env_char=$SCMPUFF_PREFIX
+  fi
 
-  # Run scmpuff status, store output
+  # The command writes the list of files to a temporary file using the
+  # NUL-delimited filelist protocol, which is safe for filenames containing
+  # any character (even tabs and newlines).
   # (`local` needs to be on its own line otherwise exit code is swallowed!)
-  local cmd_output
-  cmd_output="$(/usr/bin/env scmpuff status --filelist "$@")"
+  local filelist
+  filelist="$(mktemp)" || return
+
+  # Remove the temporary file even if the command is interrupted. zsh restores
+  # the INT trap when the function returns (localtraps), while bash has to put
+  # back the previous one by hand, and also cleans up on return.
+  if [ -n "$ZSH_VERSION" ]; then
+    setopt localoptions localtraps
+    trap 'rm -f "$filelist"' INT
+  else
+    local prev_int_trap
+    prev_int_trap="$(trap -p INT)"
+    trap 'rm -f "$filelist"; trap - RETURN; eval "${prev_int_trap:-trap - INT}"' RETURN INT
+  fi
 
-  # if there was an error, exit prematurely, and pass along the exit code
-  # (STDOUT was swallowed but not STDERR, so user should still see error msg)
+  /usr/bin/env scmpuff "$1" --filelist-out="$filelist" "${@:2}"
   local es=$?
-  if [ $es -ne 0 ]; then
+
+  # if no files were listed, such as after an error, there is nothing to export
+  if [ ! -s "$filelist" ]; then
+    rm -f "$filelist"
     return $es
   fi
 
-  # Fetch list of files (from first line of script output)
-  local files
-  files="$(echo "$cmd_output" | head -n 1)"
-
-  # Export numbered env variables for each file
+  # Export numbered env variables for each file, after verifying the protocol
+  # version in the header record, along with the fingerprint of the list
   scmpuff_clear_vars
-  IFS=$'\t'
-  local e=1
+  local header
+  local fingerprint
   local file
-  for file in $files; do
-    export $scmpuff_env_char$e="$file"
-    (( e++ ))
-  done
-  IFS=$' \t\n'
-
-  # Print status (from line two onward)
-  echo "$cmd_output" | tail -n +2
-
-  # Reset zsh environment to default
-  if [ -n "$ZSH_VERSION" ]; then unsetopt shwordsplit; fi;
+  local e=1
+  {
+    IFS= read -r -d '' header
+    if [ "$header" != "scmpuff-filelist-v3" ]; then
+      echo "scmpuff_status: unrecognized filelist protocol, please reload your shell" >&2
+      es=1
+    else
+      IFS= read -r -d '' fingerprint
+      export SCMPUFF_FINGERPRINT="$fingerprint"
+      while IFS= read -r -d '' file; do
+        export "$scmpuff_env_char$e=$file"
+        (( e++ ))
+      done
+    fi
+  } < "$filelist"
+  rm -f "$filelist"
+  return $es
 }
 
 
 # Clear numbered env variables
 scmpuff_clear_vars() {
-  local scmpuff_env_char="e"
+  local scmpuff_env_char=e
+  if [[ $SCMPUFF_PREFIX =~ ^[A-Za-z_][A-Za-z0-9_]*$ ]]; then
+    scmpuff_env_char=$SCMPUFF_PREFIX
+  fi
   local i
 
+  unset SCMPUFF_FINGERPRINT
   for (( i=1; i<=999; i++ )); do
     local env_var_i=${scmpuff_env_char}${i}
     if [[ -n ${env_var_i} ]]; then
@@ -0,0 +1,11 @@
+# File shortcuts are kept in a state file by scmpuff itself, rather than being
+# exported as environment variables, so there is nothing to do but display.
+set -gx SCMPUFF_SHORTCUTS file
+
+function scmpuff_status
+    scmpuff_run status $argv
+end
+
+function scmpuff_run
+    /usr/bin/env scmpuff $argv
+end
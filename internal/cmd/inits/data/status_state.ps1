@@ -0,0 +1,11 @@
+# File shortcuts are kept in a state file by scmpuff itself, rather than being
+# set as environment variables, so there is nothing to do but display.
+$env:SCMPUFF_SHORTCUTS = 'file'
+
+function scmpuff_status {
+  scmpuff_run status @args
+}
+
+function scmpuff_run {
+  & scmpuff @args
+}
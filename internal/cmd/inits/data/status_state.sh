@@ -0,0 +1,12 @@
+# shellcheck shell=bash
+# File shortcuts are kept in a state file by scmpuff itself, rather than being
+# exported as environment variables, so there is nothing to do but display.
+export SCMPUFF_SHORTCUTS=file
+
+scmpuff_status() {
+  scmpuff_run status "$@"
+}
+
+scmpuff_run() {
+  /usr/bin/env scmpuff "$@"
+}
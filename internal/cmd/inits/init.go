@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/mroth/scmpuff/internal/config"
+	"github.com/mroth/scmpuff/internal/shortcuts"
 	"github.com/spf13/cobra"
 )
 
@@ -15,7 +17,14 @@ func NewInitCmd() *cobra.Command {
 		shellType      string
 		includeAliases bool
 		wrapGit        bool
+		completions    bool
+		expandKey      string
+		doInstall      bool
+		doUninstall    bool
+		assumeYes      bool
 		legacyShow     bool
+		shortcutsStore string
+		prefix         string
 	)
 
 	initCmd := &cobra.Command{
@@ -32,7 +41,57 @@ For fish shell, add the following to ~/.config/fish/config.fish instead:
 
     scmpuff init --shell=fish | source
 
+For nushell, which cannot source the output of a command, save the script when
+nushell starts by adding the following to your env.nu:
+
+    scmpuff init --shell=nu | save -f ($nu.default-config-dir | path join scmpuff.nu)
+
+and source it from your config.nu:
+
+    source ($nu.default-config-dir | path join scmpuff.nu)
+
+For PowerShell, add the following to your $PROFILE:
+
+    Invoke-Expression (& scmpuff init --shell=pwsh | Out-String)
+
+Tab completion and --expand-key are not available in nushell and PowerShell.
+
 There are a number of flags to customize the shell integration.
+
+By default, file shortcuts are exported as environment variables ($e1, $e2...).
+With --shortcuts=file, they are instead kept by scmpuff in a state file for
+each repository, under $XDG_STATE_HOME/scmpuff (~/.local/state by default).
+This keeps the environment of every process small, and the shortcuts survive
+into new terminals, but they are shared by all shells in the same repository.
+
+The "e" in $e1 can be changed with --prefix (e.g. --prefix=f for $f1, $f2...),
+or by setting $` + shortcuts.PrefixEnvVar + ` in your environment.
+
+With --expand-key, a key is bound to expand the numeric shortcut or range under
+the cursor in the command line into the paths it refers to, so that they can be
+used with any command and edited before running it. The key is given as C-<letter>
+for Ctrl or M-<letter> for Alt, e.g. --expand-key=M-z turns 'vim 3' into
+'vim src/app/main.go' on pressing Alt-Z. Keys that the terminal or shell rely
+on, such as C-m (Enter), C-i (Tab), C-c or the C-x prefix, are refused.
+
+Tab completion is included for scmpuff itself, and for numeric shortcuts in git
+commands, e.g. 'git add 3<TAB>' (see 'scmpuff completion'). In zsh, this needs
+compinit to have been run beforehand.
+
+Rather than editing your shell startup file by hand, you can run:
+
+    scmpuff init --install
+
+This adds a marked block loading scmpuff to the startup file of your shell
+(~/.bashrc, or ~/.bash_profile on macOS; ~/.zshrc, in $ZDOTDIR if set; or
+config.fish and the PowerShell profile, in $XDG_CONFIG_HOME if set), detected
+from $SHELL unless --shell is given. Any flags customizing the integration are
+included in the block, and running it again updates the block in place. The
+changes are shown as a diff and, in a terminal, confirmed before being written
+(skip with --yes). 'scmpuff init --uninstall' removes the block again.
+
+Flags that are not given default to the init.* settings, if configured (see
+'scmpuff config'), e.g. 'scmpuff config set init.shortcuts file'.
     `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// If someone's using the old ---show flag, opt-in to the newer --shell defaults
@@ -40,13 +99,98 @@ There are a number of flags to customize the shell integration.
 				shellType = defaultShellType()
 			}
 
+			// Settings not given as flags fall back to the scmpuff config.
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			flags := cmd.Flags()
+			if !flags.Changed("aliases") {
+				if includeAliases, err = cfg.Bool("init.aliases"); err != nil {
+					return err
+				}
+			}
+			if !flags.Changed("wrap") {
+				if wrapGit, err = cfg.Bool("init.wrap"); err != nil {
+					return err
+				}
+			}
+			if !flags.Changed("completions") {
+				if completions, err = cfg.Bool("init.completions"); err != nil {
+					return err
+				}
+			}
+			if !flags.Changed("expand-key") {
+				if expandKey, err = cfg.String("init.expandKey"); err != nil {
+					return err
+				}
+			}
+			if !flags.Changed("shortcuts") {
+				if shortcutsStore, err = cfg.String("init.shortcuts"); err != nil {
+					return err
+				}
+			}
+			if !flags.Changed("prefix") {
+				v, err := cfg.Get("init.prefix")
+				if err != nil {
+					return err
+				}
+				// a prefix from the environment is already in effect as is
+				if v.Source != config.SourceDefault && v.Source != config.SourceEnv {
+					prefix = v.Value
+				}
+			}
+
+			opts := outputOptions{
+				wrapGit:     wrapGit,
+				prefix:      prefix,
+				expandKey:   expandKey,
+				completions: completions,
+			}
+			if includeAliases {
+				opts.aliases = cfg.Aliases()
+			}
+			switch shortcutsStore {
+			case "env":
+			case "file":
+				opts.stateFile = true
+			default:
+				return fmt.Errorf(`unrecognized shortcuts store "%s"`, shortcutsStore)
+			}
+			if expandKey != "" {
+				if _, _, err := config.ParseKey(expandKey); err != nil {
+					return fmt.Errorf(`invalid expand key "%s": %w`, expandKey, err)
+				}
+			}
+			if prefix != "" && !shortcuts.ValidPrefix(prefix) {
+				return fmt.Errorf(`invalid shortcut variable prefix "%s": must be a valid shell variable name`, prefix)
+			}
+
+			if doInstall || doUninstall {
+				cmd.SilenceUsage = true
+				if shellType == "" {
+					shellType = defaultShellType()
+				}
+				var initArgs []string
+				for _, name := range []string{"aliases", "wrap", "completions", "expand-key", "shortcuts", "prefix"} {
+					if f := flags.Lookup(name); f.Changed {
+						initArgs = append(initArgs, "--"+name+"="+f.Value.String())
+					}
+				}
+				return install(cmd, strings.ToLower(shellType), initArgs, doUninstall, assumeYes)
+			}
+
 			switch strings.ToLower(shellType) {
 			case "":
 				cmd.Help()
 			case "sh", "bash", "zsh":
-				fmt.Fprintln(cmd.OutOrStdout(), bashCollection.Output(wrapGit, includeAliases))
+				fmt.Fprintln(cmd.OutOrStdout(), bashCollection.Output(opts))
 			case "fish":
-				fmt.Fprintln(cmd.OutOrStdout(), fishCollection.Output(wrapGit, includeAliases))
+				fmt.Fprintln(cmd.OutOrStdout(), fishCollection.Output(opts))
+			case "nu":
+				fmt.Fprintln(cmd.OutOrStdout(), nuCollection.Output(opts))
+			case "pwsh":
+				fmt.Fprintln(cmd.OutOrStdout(), pwshCollection.Output(opts))
 			default:
 				return fmt.Errorf(`unrecognized shell "%s"`, shellType)
 			}
@@ -79,13 +223,67 @@ There are a number of flags to customize the shell integration.
 		"Wrap standard git commands",
 	)
 
+	// --completions
+	initCmd.Flags().BoolVar(
+		&completions,
+		"completions", true,
+		"Include tab completion for scmpuff and file shortcuts",
+	)
+
+	// --expand-key
+	initCmd.Flags().StringVar(
+		&expandKey,
+		"expand-key", "",
+		"Key expanding the shortcut at the cursor into its paths, e.g. M-z",
+	)
+
+	// --shortcuts
+	initCmd.Flags().StringVar(
+		&shortcutsStore,
+		"shortcuts", "env",
+		"Where file shortcuts are kept: env | file",
+	)
+	initCmd.RegisterFlagCompletionFunc("shortcuts", cobra.FixedCompletions(
+		[]cobra.Completion{"env", "file"}, cobra.ShellCompDirectiveNoFileComp,
+	))
+
+	// --prefix
+	initCmd.Flags().StringVar(
+		&prefix,
+		"prefix", "",
+		"Prefix of shortcut variable names (default $"+shortcuts.PrefixEnvVar+` or "`+shortcuts.DefaultPrefix+`")`,
+	)
+
+	// --install, --uninstall
+	initCmd.Flags().BoolVar(
+		&doInstall,
+		"install", false,
+		"Add the initialization to the startup file of the shell",
+	)
+	initCmd.Flags().BoolVar(
+		&doUninstall,
+		"uninstall", false,
+		"Remove the initialization added by --install",
+	)
+	initCmd.MarkFlagsMutuallyExclusive("install", "uninstall")
+
+	// --yes
+	initCmd.Flags().BoolVarP(
+		&assumeYes,
+		"yes", "y", false,
+		"Skip confirmation of changes made by --install or --uninstall",
+	)
+
 	// --shell
 	initCmd.Flags().StringVarP(
 		&shellType,
 		"shell", "s", "",
-		"Output shell type: sh | bash | zsh | fish",
+		"Output shell type: sh | bash | zsh | fish | nu | pwsh",
 	)
 	initCmd.Flag("shell").NoOptDefVal = defaultShellType()
+	initCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(
+		[]cobra.Completion{"sh", "bash", "zsh", "fish", "nu", "pwsh"}, cobra.ShellCompDirectiveNoFileComp,
+	))
 
 	return initCmd
 }
@@ -95,7 +293,7 @@ func defaultShellType() string {
 	if shellenv, ok := os.LookupEnv("SHELL"); ok {
 		base := filepath.Base(shellenv)
 		switch base {
-		case "sh", "bash", "zsh", "fish":
+		case "sh", "bash", "zsh", "fish", "nu", "pwsh":
 			return base
 		}
 	}
@@ -2,10 +2,18 @@ package inits
 
 import (
 	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/mroth/scmpuff/internal/config"
 )
 
+var updateGolden = flag.Bool("update", false, "update the golden files of this test")
+
 func Test_defaultShellType(t *testing.T) {
 	tests := []struct {
 		shellenv string
@@ -17,6 +25,8 @@ func Test_defaultShellType(t *testing.T) {
 		{"/usr/local/bin/zsh", "zsh"},
 		{"/bin/bash", "bash"},
 		{"/usr/local/bin/fish", "fish"},
+		{"/usr/bin/nu", "nu"},
+		{"/opt/microsoft/powershell/7/pwsh", "pwsh"},
 
 		// edge cases
 		{"", "sh"},
@@ -30,8 +40,35 @@ func Test_defaultShellType(t *testing.T) {
 	}
 }
 
+// isolateConfig runs the test with the given user config file contents in
+// place of any scmpuff configuration from the host.
+func isolateConfig(t *testing.T, contents string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("SCMPUFF_PREFIX", "")
+	t.Setenv("SCMPUFF_SHORTCUTS", "")
+	t.Chdir(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "scmpuff"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "scmpuff", "config.toml"), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func executeInitCmd(t *testing.T, args ...string) (stdout string, stderr string, err error) {
 	t.Helper()
+	isolateConfig(t, "")
+	return runInitCmd(t, args...)
+}
+
+// runInitCmd is executeInitCmd using the current configuration.
+func runInitCmd(t *testing.T, args ...string) (stdout string, stderr string, err error) {
+	t.Helper()
 
 	cmd := NewInitCmd()
 	cmd.SetArgs(args)
@@ -107,7 +144,7 @@ func TestNewInitCmd_AliasesFlagControlsOutput(t *testing.T) {
 					t.Errorf("unexpected stderr: %q", stderr)
 				}
 
-				gotAliases := strings.Contains(stdout, scriptAliases)
+				gotAliases := strings.Contains(stdout, "alias gs")
 				if gotAliases != tt.wantAliases {
 					t.Errorf("aliases script presence = %v, want %v", gotAliases, tt.wantAliases)
 				}
@@ -122,8 +159,8 @@ func TestNewInitCmd_WrapFlagControlsOutput(t *testing.T) {
 		wantWrapScript string
 	}
 	shells := []shellInfo{
-		{name: "bash", wantWrapScript: scriptGitWrapper},
-		{name: "fish", wantWrapScript: scriptGitWrapperFish},
+		{name: "bash", wantWrapScript: "function git() {\n"},
+		{name: "fish", wantWrapScript: "function git\n"},
 	}
 
 	tests := []struct {
@@ -157,3 +194,275 @@ func TestNewInitCmd_WrapFlagControlsOutput(t *testing.T) {
 		}
 	}
 }
+
+func TestNewInitCmd_CompletionsFlagControlsOutput(t *testing.T) {
+	shells := []struct {
+		name       string
+		wantScript string
+	}{
+		{name: "bash", wantScript: "scmpuff completion bash"},
+		{name: "zsh", wantScript: "scmpuff completion zsh"},
+		{name: "fish", wantScript: "scmpuff completion fish"},
+	}
+
+	tests := []struct {
+		name            string
+		config          string
+		flagArgs        []string
+		wantCompletions bool
+	}{
+		{name: "default true", wantCompletions: true},
+		{name: "explicit false", flagArgs: []string{"--completions=false"}, wantCompletions: false},
+		{name: "config false", config: "[init]\ncompletions = false\n", wantCompletions: false},
+		{name: "flag overrides config", config: "[init]\ncompletions = false\n", flagArgs: []string{"--completions"}, wantCompletions: true},
+	}
+
+	for _, shell := range shells {
+		for _, tt := range tests {
+			t.Run(shell.name+"/"+tt.name, func(t *testing.T) {
+				isolateConfig(t, tt.config)
+				args := append([]string{"--shell=" + shell.name}, tt.flagArgs...)
+				stdout, _, err := runInitCmd(t, args...)
+				if err != nil {
+					t.Fatalf("execute init failed: %v", err)
+				}
+
+				got := strings.Contains(stdout, shell.wantScript)
+				if got != tt.wantCompletions {
+					t.Errorf("completion script presence = %v, want %v", got, tt.wantCompletions)
+				}
+			})
+		}
+	}
+}
+
+func TestNewInitCmd_ExpandKeyFlag(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		flagArgs   []string
+		wantScript bool
+		wantErr    error
+	}{
+		{name: "default none", wantScript: false},
+		{name: "flag", flagArgs: []string{"--expand-key=M-z"}, wantScript: true},
+		{name: "config", config: "[init]\nexpandKey = \"M-e\"\n", wantScript: true},
+		{name: "flag overrides config", config: "[init]\nexpandKey = \"M-e\"\n", flagArgs: []string{"--expand-key="}, wantScript: false},
+		{name: "invalid", flagArgs: []string{"--expand-key=ctrl-x"}, wantErr: config.ErrInvalidKey},
+		{name: "reserved", flagArgs: []string{"--expand-key=C-m"}, wantErr: config.ErrReservedKey},
+		{name: "reserved in config", config: "[init]\nexpandKey = \"C-x\"\n", wantErr: config.ErrReservedKey},
+	}
+
+	for _, shell := range []string{"bash", "fish"} {
+		for _, tt := range tests {
+			t.Run(shell+"/"+tt.name, func(t *testing.T) {
+				isolateConfig(t, tt.config)
+				args := append([]string{"--shell=" + shell}, tt.flagArgs...)
+				stdout, _, err := runInitCmd(t, args...)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("execute init failed: %v", err)
+				}
+
+				got := strings.Contains(stdout, "scmpuff expand -r -z")
+				if got != tt.wantScript {
+					t.Errorf("expand key script presence = %v, want %v", got, tt.wantScript)
+				}
+			})
+		}
+	}
+}
+
+func TestNewInitCmd_ShortcutsFlagControlsOutput(t *testing.T) {
+	tests := []struct {
+		shell      string
+		flagArgs   []string
+		wantScript string
+		dontScript string
+	}{
+		{shell: "bash", flagArgs: nil, wantScript: scriptStatusShortcuts, dontScript: scriptStatusState},
+		{shell: "bash", flagArgs: []string{"--shortcuts=env"}, wantScript: scriptStatusShortcuts, dontScript: scriptStatusState},
+		{shell: "bash", flagArgs: []string{"--shortcuts=file"}, wantScript: scriptStatusState, dontScript: scriptStatusShortcuts},
+		{shell: "fish", flagArgs: nil, wantScript: scriptStatusShortcutsFish, dontScript: scriptStatusStateFish},
+		{shell: "fish", flagArgs: []string{"--shortcuts=file"}, wantScript: scriptStatusStateFish, dontScript: scriptStatusShortcutsFish},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell+"/"+strings.Join(tt.flagArgs, " "), func(t *testing.T) {
+			args := append([]string{"--shell=" + tt.shell}, tt.flagArgs...)
+			stdout, _, err := executeInitCmd(t, args...)
+			if err != nil {
+				t.Fatalf("execute init failed: %v", err)
+			}
+			if !strings.Contains(stdout, tt.wantScript) {
+				t.Errorf("expected output to contain status script")
+			}
+			if strings.Contains(stdout, tt.dontScript) {
+				t.Errorf("expected output to not contain other status script")
+			}
+		})
+	}
+
+	if _, _, err := executeInitCmd(t, "--shell=bash", "--shortcuts=registry"); err == nil {
+		t.Errorf("expected error for unrecognized shortcuts store")
+	}
+}
+
+func TestNewInitCmd_PrefixFlag(t *testing.T) {
+	tests := []struct {
+		shell    string
+		flagArgs []string
+		want     string
+	}{
+		{shell: "bash", flagArgs: []string{"--prefix=f"}, want: "export SCMPUFF_PREFIX=f\n"},
+		{shell: "fish", flagArgs: []string{"--prefix=f"}, want: "set -gx SCMPUFF_PREFIX f\n"},
+		{shell: "bash", flagArgs: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell+"/"+strings.Join(tt.flagArgs, " "), func(t *testing.T) {
+			args := append([]string{"--shell=" + tt.shell}, tt.flagArgs...)
+			stdout, _, err := executeInitCmd(t, args...)
+			if err != nil {
+				t.Fatalf("execute init failed: %v", err)
+			}
+			if tt.want == "" {
+				if strings.Contains(stdout, "SCMPUFF_PREFIX=") || strings.Contains(stdout, "set -gx SCMPUFF_PREFIX") {
+					t.Errorf("expected no prefix to be set")
+				}
+			} else if !strings.HasPrefix(stdout, tt.want) {
+				t.Errorf("expected output to start with %q", tt.want)
+			}
+		})
+	}
+
+	if _, _, err := executeInitCmd(t, "--shell=bash", "--prefix=$(rm -rf)"); err == nil {
+		t.Errorf("expected error for invalid prefix")
+	}
+}
+
+func TestNewInitCmd_ConfigDefaults(t *testing.T) {
+	isolateConfig(t, "[init]\nprefix = \"f\"\nshortcuts = \"file\"\naliases = false\n")
+
+	stdout, _, err := runInitCmd(t, "--shell=bash")
+	if err != nil {
+		t.Fatalf("execute init failed: %v", err)
+	}
+	if !strings.HasPrefix(stdout, "export SCMPUFF_PREFIX=f\n") {
+		t.Errorf("expected configured prefix to be exported")
+	}
+	if !strings.Contains(stdout, "export SCMPUFF_SHORTCUTS=file") {
+		t.Errorf("expected configured state file shortcuts")
+	}
+	if strings.Contains(stdout, "alias gs=") {
+		t.Errorf("expected configured aliases to be disabled")
+	}
+
+	// flags take precedence over the config
+	stdout, _, err = runInitCmd(t, "--shell=bash", "--prefix=g", "--aliases")
+	if err != nil {
+		t.Fatalf("execute init failed: %v", err)
+	}
+	if !strings.HasPrefix(stdout, "export SCMPUFF_PREFIX=g\n") {
+		t.Errorf("expected --prefix to override config")
+	}
+	if !strings.Contains(stdout, "alias gs=") {
+		t.Errorf("expected --aliases to override config")
+	}
+}
+
+func TestNewInitCmd_ConfiguredAliases(t *testing.T) {
+	isolateConfig(t, `[alias]
+gcm = "git commit -m"
+gq = "echo it's a\\b"
+gs = ""
+`)
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{shell: "bash", want: []string{"alias gcm='git commit -m'\n", `alias gq='echo it'\''s a\b'` + "\n"}},
+		{shell: "fish", want: []string{"alias gcm 'git commit -m'\n", `alias gq 'echo it\'s a\\b'` + "\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			stdout, _, err := runInitCmd(t, "--shell="+tt.shell)
+			if err != nil {
+				t.Fatalf("execute init failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("expected output to contain %q", want)
+				}
+			}
+			if strings.Contains(stdout, "alias gs") {
+				t.Errorf("expected disabled alias gs to be omitted")
+			}
+		})
+	}
+}
+
+// The nushell and PowerShell scripts cannot be checked by the testscripts where
+// these shells are not installed, so the generated scripts are compared in full.
+func TestNewInitCmd_Golden(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+	}{
+		{name: "default"},
+		{name: "state-file", args: []string{"--shortcuts=file", "--aliases=false"}},
+		{name: "prefix-no-wrap", args: []string{"--prefix=f", "--wrap=false", "--aliases=false"}},
+		{name: "configured-aliases", config: "[alias]\ngcm = \"git commit -m\"\ngd = \"\"\n"},
+		{name: "unsupported-features", args: []string{"--expand-key=M-z", "--completions", "--aliases=false"}},
+	}
+	testdata, err := filepath.Abs("testdata") // before the tests change directory
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, shell := range []string{"nu", "pwsh"} {
+		for _, tt := range tests {
+			t.Run(shell+"/"+tt.name, func(t *testing.T) {
+				isolateConfig(t, tt.config)
+				stdout, _, err := runInitCmd(t, append([]string{"--shell=" + shell}, tt.args...)...)
+				if err != nil {
+					t.Fatalf("execute init failed: %v", err)
+				}
+				goldenPath := filepath.Join(testdata, "init-"+shell+"-"+tt.name+".golden")
+				goldenCompareFile(t, goldenPath, []byte(stdout), *updateGolden)
+			})
+		}
+	}
+}
+
+func goldenCompareFile(t *testing.T, goldenPath string, actual []byte, update bool) {
+	t.Helper()
+
+	if update {
+		err := os.MkdirAll(filepath.Dir(goldenPath), 0755)
+		if err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		err = os.WriteFile(goldenPath, actual, 0644)
+		if err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		t.Logf("updated golden file: %s [%v bytes]", goldenPath, len(actual))
+	}
+
+	goldenData, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s: %v\nRun with -update to create it", goldenPath, err)
+	}
+
+	if !bytes.Equal(goldenData, actual) {
+		t.Errorf("actual doesn't match golden file %s\nExpected:\n%s\nActual:\n%s",
+			goldenPath, goldenData, actual)
+	}
+}
@@ -0,0 +1,270 @@
+package inits
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// Markers delimiting the block added to a shell startup file by --install, so
+// that it can be found again to be updated or removed.
+const (
+	blockBegin = "# >>> scmpuff >>>"
+	blockEnd   = "# <<< scmpuff <<<"
+)
+
+// ErrUnsupportedShell is returned when --install does not support the shell.
+var ErrUnsupportedShell = errors.New("not supported by --install")
+
+// install adds the marked block loading the initialization script of shell to
+// its startup file, passing along the init flags given as args, or replaces it
+// if already there. With uninstall, the block is removed instead.
+func install(cmd *cobra.Command, shell string, args []string, uninstall, assumeYes bool) error {
+	path, err := startupFile(shell)
+	if err != nil {
+		return err
+	}
+	edit := func(contents string) string {
+		return withBlock(contents, installBlock(initLine(shell, args)))
+	}
+	if uninstall {
+		edit = withoutBlock
+	}
+
+	w := cmd.OutOrStdout()
+	interactive := isatty.IsTerminal(os.Stdin.Fd())
+	changed, err := updateFile(w, cmd.InOrStdin(), path, edit, interactive, assumeYes)
+	if err != nil {
+		return err
+	}
+	switch {
+	case changed && uninstall:
+		fmt.Fprintf(w, "Removed scmpuff from %s, restart your shell to unload it.\n", path)
+	case changed:
+		fmt.Fprintf(w, "Installed scmpuff in %s, restart your shell to load it.\n", path)
+	case uninstall:
+		fmt.Fprintf(w, "scmpuff is not installed in %s.\n", path)
+	default:
+		fmt.Fprintf(w, "scmpuff is already installed in %s.\n", path)
+	}
+
+	// an initialization added by hand would still be loaded, or loaded twice
+	if data, err := os.ReadFile(path); err == nil && strings.Contains(withoutBlock(string(data)), "scmpuff init") {
+		fmt.Fprintf(cmd.ErrOrStderr(), "note: %s also runs 'scmpuff init' outside of the scmpuff block, which you may want to remove.\n", path)
+	}
+	return nil
+}
+
+// startupFile returns the path of the startup file of shell, in which the
+// initialization of scmpuff is installed.
+func startupFile(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	configHome := filepath.Join(home, ".config")
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		configHome = dir
+	}
+
+	switch shell {
+	case "bash":
+		// macOS terminals start login shells, which only read ~/.bash_profile
+		if runtime.GOOS == "darwin" {
+			return filepath.Join(home, ".bash_profile"), nil
+		}
+		return filepath.Join(home, ".bashrc"), nil
+	case "zsh":
+		if dir := os.Getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zshrc"), nil
+		}
+		return filepath.Join(home, ".zshrc"), nil
+	case "fish":
+		return filepath.Join(configHome, "fish", "config.fish"), nil
+	case "pwsh":
+		return filepath.Join(configHome, "powershell", "Microsoft.PowerShell_profile.ps1"), nil
+	case "nu":
+		return "", fmt.Errorf("nushell is %w, see 'scmpuff init --help' to set it up", ErrUnsupportedShell)
+	}
+	return "", fmt.Errorf(`shell "%s" is %w, choose one of bash, zsh, fish or pwsh with --shell`, shell, ErrUnsupportedShell)
+}
+
+// initLine returns the line of shell code loading the initialization script in
+// shell, passing along the init flags given as args (e.g. "--aliases=false").
+func initLine(shell string, args []string) string {
+	switch shell {
+	case "fish":
+		return strings.Join(append([]string{"scmpuff init --shell=fish"}, args...), " ") + " | source"
+	case "pwsh":
+		return "Invoke-Expression (& " + strings.Join(append([]string{"scmpuff init --shell=pwsh"}, args...), " ") + " | Out-String)"
+	}
+	return `eval "$(` + strings.Join(append([]string{"scmpuff init -s"}, args...), " ") + `)"`
+}
+
+// installBlock returns the marked block loading the initialization script with
+// line (see initLine).
+func installBlock(line string) string {
+	return blockBegin + "\n" +
+		"# added by 'scmpuff init --install', remove with 'scmpuff init --uninstall'\n" +
+		line + "\n" +
+		blockEnd + "\n"
+}
+
+// findBlock returns the start and end offsets of the marked block in contents,
+// including the newline ending it, and whether there is one.
+func findBlock(contents string) (start, end int, ok bool) {
+	start = strings.Index(contents, blockBegin+"\n")
+	if start == -1 || (start > 0 && contents[start-1] != '\n') {
+		return 0, 0, false
+	}
+	n := strings.Index(contents[start:], "\n"+blockEnd)
+	if n == -1 {
+		return 0, 0, false
+	}
+	end = start + n + len("\n"+blockEnd)
+	if end < len(contents) && contents[end] == '\n' {
+		end++
+	}
+	return start, end, true
+}
+
+// withBlock returns contents with block in place of the marked block, or
+// appended after a blank line if there is none.
+func withBlock(contents, block string) string {
+	if start, end, ok := findBlock(contents); ok {
+		return contents[:start] + block + contents[end:]
+	}
+	switch {
+	case contents == "":
+	case strings.HasSuffix(contents, "\n\n"):
+	case strings.HasSuffix(contents, "\n"):
+		contents += "\n"
+	default:
+		contents += "\n\n"
+	}
+	return contents + block
+}
+
+// withoutBlock returns contents without the marked block, along with the blank
+// line separating it from what comes before, as added by withBlock.
+func withoutBlock(contents string) string {
+	start, end, ok := findBlock(contents)
+	if !ok {
+		return contents
+	}
+	before := contents[:start]
+	if strings.HasSuffix(before, "\n\n") && end == len(contents) {
+		before = before[:len(before)-1]
+	}
+	return before + contents[end:]
+}
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 3
+
+// unifiedDiff returns the changes between before and after, the old and new
+// contents of the file at path, in unified diff format. The block is the only
+// part of the file that changes, so the changes are found as the lines between
+// the longest common prefix and suffix.
+func unifiedDiff(path, before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	if prefix == len(a) && prefix == len(b) {
+		return ""
+	}
+
+	from := max(prefix-diffContext, 0)
+	aTo, bTo := min(len(a)-suffix+diffContext, len(a)), min(len(b)-suffix+diffContext, len(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", path, path)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(from, aTo), hunkRange(from, bTo))
+	for _, line := range a[from:prefix] {
+		sb.WriteString(" " + line + "\n")
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		sb.WriteString("-" + line + "\n")
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		sb.WriteString("+" + line + "\n")
+	}
+	for _, line := range a[len(a)-suffix : aTo] {
+		sb.WriteString(" " + line + "\n")
+	}
+	return sb.String()
+}
+
+// splitLines returns the lines of s, without their newlines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// hunkRange formats the range of lines [from, to) of a hunk header.
+func hunkRange(from, to int) string {
+	if from == to {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+// updateFile shows the changes that edit makes to the contents of the file at
+// path on w and, once confirmed, writes them. It reports whether the file was
+// changed.
+//
+// Confirmation is read from r if interactive, unless assumeYes is set, and is
+// not requested otherwise, so that scripts are never blocked.
+func updateFile(w io.Writer, r io.Reader, path string, edit func(string) string, interactive, assumeYes bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	perm := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	before := string(data)
+	after := edit(before)
+	if after == before {
+		return false, nil
+	}
+
+	fmt.Fprint(w, unifiedDiff(path, before, after))
+	if interactive && !assumeYes {
+		fmt.Fprintf(w, "Write changes to %s? [y/N] ", path)
+		answer, err := bufio.NewReader(r).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return false, errors.New("aborted, no changes were written")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(path, []byte(after), perm); err != nil {
+		return false, err
+	}
+	return true, nil
+}
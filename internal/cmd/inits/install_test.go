@@ -0,0 +1,209 @@
+package inits
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_startupFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	bashrc := filepath.Join(home, ".bashrc")
+	if runtime.GOOS == "darwin" {
+		bashrc = filepath.Join(home, ".bash_profile")
+	}
+	tests := []struct {
+		name, shell  string
+		zdotdir, xdg string
+		want         string
+		wantErr      error
+	}{
+		{name: "bash", shell: "bash", want: bashrc},
+		{name: "zsh", shell: "zsh", want: filepath.Join(home, ".zshrc")},
+		{name: "zsh ZDOTDIR", shell: "zsh", zdotdir: "/zdot", want: "/zdot/.zshrc"},
+		{name: "fish", shell: "fish", want: filepath.Join(home, ".config", "fish", "config.fish")},
+		{name: "fish XDG_CONFIG_HOME", shell: "fish", xdg: "/xdg", want: "/xdg/fish/config.fish"},
+		{name: "fish relative XDG_CONFIG_HOME", shell: "fish", xdg: "xdg", want: filepath.Join(home, ".config", "fish", "config.fish")},
+		{name: "pwsh", shell: "pwsh", xdg: "/xdg", want: "/xdg/powershell/Microsoft.PowerShell_profile.ps1"},
+		{name: "nu", shell: "nu", wantErr: ErrUnsupportedShell},
+		{name: "sh", shell: "sh", wantErr: ErrUnsupportedShell},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ZDOTDIR", tt.zdotdir)
+			t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+			got, err := startupFile(tt.shell)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("startupFile(%q) error = %v, want %v", tt.shell, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("startupFile(%q) = %q, want %q", tt.shell, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_initLine(t *testing.T) {
+	tests := []struct {
+		shell string
+		args  []string
+		want  string
+	}{
+		{"bash", nil, `eval "$(scmpuff init -s)"`},
+		{"zsh", []string{"--aliases=false"}, `eval "$(scmpuff init -s --aliases=false)"`},
+		{"fish", []string{"--prefix=f"}, `scmpuff init --shell=fish --prefix=f | source`},
+		{"pwsh", nil, `Invoke-Expression (& scmpuff init --shell=pwsh | Out-String)`},
+	}
+	for _, tt := range tests {
+		if got := initLine(tt.shell, tt.args); got != tt.want {
+			t.Errorf("initLine(%q, %q) = %q, want %q", tt.shell, tt.args, got, tt.want)
+		}
+	}
+}
+
+func Test_withBlock(t *testing.T) {
+	block := installBlock("LINE")
+	other := installBlock("OTHER")
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{name: "empty", contents: "", want: block},
+		{name: "no final newline", contents: "a", want: "a\n\n" + block},
+		{name: "final newline", contents: "a\n", want: "a\n\n" + block},
+		{name: "blank line", contents: "a\n\n", want: "a\n\n" + block},
+		{name: "already installed", contents: "a\n\n" + block, want: "a\n\n" + block},
+		{name: "replaced in place", contents: "a\n" + other + "b\n", want: "a\n" + block + "b\n"},
+		{name: "marker within a line", contents: "x" + blockBegin + "\n", want: "x" + blockBegin + "\n\n" + block},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withBlock(tt.contents, block); got != tt.want {
+				t.Errorf("withBlock(%q) = %q, want %q", tt.contents, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withoutBlock(t *testing.T) {
+	block := installBlock("LINE")
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{name: "not installed", contents: "a\n", want: "a\n"},
+		{name: "only block", contents: block, want: ""},
+		{name: "appended", contents: "a\n\n" + block, want: "a\n"},
+		{name: "in the middle", contents: "a\n\n" + block + "b\n", want: "a\n\nb\n"},
+		{name: "unterminated", contents: "a\n" + blockBegin + "\nLINE\n", want: "a\n" + blockBegin + "\nLINE\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withoutBlock(tt.contents); got != tt.want {
+				t.Errorf("withoutBlock(%q) = %q, want %q", tt.contents, got, tt.want)
+			}
+		})
+	}
+
+	// uninstalling restores the file as it was before installing, but for a
+	// missing final newline
+	for contents, want := range map[string]string{"": "", "a\n": "a\n", "a": "a\n"} {
+		if got := withoutBlock(withBlock(contents, block)); got != want {
+			t.Errorf("withoutBlock(withBlock(%q)) = %q, want %q", contents, got, want)
+		}
+	}
+}
+
+func Test_unifiedDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{
+			name:   "unchanged",
+			before: "a\n", after: "a\n",
+			want: "",
+		},
+		{
+			name:   "added to empty file",
+			before: "", after: "x\ny\n",
+			want: "--- f\n+++ f\n@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+		{
+			name:   "appended with context",
+			before: "1\n2\n3\n4\n", after: "1\n2\n3\n4\nx\n",
+			want: "--- f\n+++ f\n@@ -2,3 +2,4 @@\n 2\n 3\n 4\n+x\n",
+		},
+		{
+			name:   "replaced in the middle",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n", after: "1\n2\n3\n4\nx\n6\n7\n8\n",
+			want: "--- f\n+++ f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n",
+		},
+		{
+			name:   "removed",
+			before: "1\nx\n", after: "1\n",
+			want: "--- f\n+++ f\n@@ -1,2 +1,1 @@\n 1\n-x\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f", tt.before, tt.after); got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_updateFile(t *testing.T) {
+	edit := func(s string) string { return s + "x\n" }
+
+	tests := []struct {
+		name        string
+		interactive bool
+		assumeYes   bool
+		answer      string
+		wantChanged bool
+		wantErr     bool
+	}{
+		{name: "non-interactive", wantChanged: true},
+		{name: "confirmed", interactive: true, answer: "y\n", wantChanged: true},
+		{name: "declined", interactive: true, answer: "n\n", wantErr: true},
+		{name: "no answer", interactive: true, answer: "", wantErr: true},
+		{name: "assume yes", interactive: true, assumeYes: true, wantChanged: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dir", "rc")
+			var out strings.Builder
+			changed, err := updateFile(&out, strings.NewReader(tt.answer), path, edit, tt.interactive, tt.assumeYes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("updateFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("updateFile() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !strings.Contains(out.String(), "+x\n") {
+				t.Errorf("expected the diff to be shown, got:\n%s", out.String())
+			}
+
+			data, _ := os.ReadFile(path)
+			if got := string(data) == "x\n"; got != tt.wantChanged {
+				t.Errorf("file written = %v, want %v", got, tt.wantChanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package inits
+
+import (
+	"fmt"
+
+	"github.com/mroth/scmpuff/internal/config"
+)
+
+// bindKeySh returns the script binding key (see config.ParseKey) to the
+// expansion of shortcuts, in zsh or interactive bash.
+func bindKeySh(key string) string {
+	modifier, letter, _ := config.ParseKey(key)
+	zshKey, bashKey := `^`+string(letter), `\C-`+string(letter)
+	if modifier == 'M' {
+		zshKey, bashKey = `^[`+string(letter), `\e`+string(letter)
+	}
+	return fmt.Sprintf(`if [ -n "$ZSH_VERSION" ]; then
+  if [[ -o zle ]]; then
+    zle -N _scmpuff_expand_zle
+    bindkey '%s' _scmpuff_expand_zle
+  fi
+elif [[ $- == *i* ]]; then
+  bind -x '"%s": _scmpuff_expand_readline'
+fi
+`, zshKey, bashKey)
+}
+
+// bindKeyFish returns the script binding key (see config.ParseKey) to the
+// expansion of shortcuts, in fish, including its vi insert mode.
+func bindKeyFish(key string) string {
+	modifier, letter, _ := config.ParseKey(key)
+	fishKey := `\c` + string(letter)
+	if modifier == 'M' {
+		fishKey = `\e` + string(letter)
+	}
+	return fmt.Sprintf("bind %[1]s __scmpuff_expand_shortcut\nbind -M insert %[1]s __scmpuff_expand_shortcut\n", fishKey)
+}
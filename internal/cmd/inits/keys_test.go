@@ -0,0 +1,39 @@
+package inits
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_bindKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		wantSh   []string
+		wantFish string
+	}{
+		{
+			key:      "C-o",
+			wantSh:   []string{`bindkey '^o' _scmpuff_expand_zle`, `bind -x '"\C-o": _scmpuff_expand_readline'`},
+			wantFish: `bind \co __scmpuff_expand_shortcut`,
+		},
+		{
+			key:      "M-e",
+			wantSh:   []string{`bindkey '^[e' _scmpuff_expand_zle`, `bind -x '"\ee": _scmpuff_expand_readline'`},
+			wantFish: `bind \ee __scmpuff_expand_shortcut`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			sh := bindKeySh(tt.key)
+			for _, want := range tt.wantSh {
+				if !strings.Contains(sh, want) {
+					t.Errorf("bindKeySh(%q) missing %q in:\n%s", tt.key, want, sh)
+				}
+			}
+			fish := bindKeyFish(tt.key)
+			if !strings.Contains(fish, tt.wantFish+"\n") || !strings.Contains(fish, "bind -M insert ") {
+				t.Errorf("bindKeyFish(%q) = %q, want binding %q in both modes", tt.key, fish, tt.wantFish)
+			}
+		})
+	}
+}
@@ -2,7 +2,11 @@ package inits
 
 import (
 	_ "embed"
+	"fmt"
 	"strings"
+
+	"github.com/mroth/scmpuff/internal/config"
+	"github.com/mroth/scmpuff/internal/shortcuts"
 )
 
 //go:embed data/status_shortcuts.sh
@@ -11,8 +15,11 @@ var scriptStatusShortcuts string
 //go:embed data/status_shortcuts.fish
 var scriptStatusShortcutsFish string
 
-//go:embed data/aliases.sh
-var scriptAliases string
+//go:embed data/status_state.sh
+var scriptStatusState string
+
+//go:embed data/status_state.fish
+var scriptStatusStateFish string
 
 //go:embed data/git_wrapper.sh
 var scriptGitWrapper string
@@ -20,34 +27,170 @@ var scriptGitWrapper string
 //go:embed data/git_wrapper.fish
 var scriptGitWrapperFish string
 
+//go:embed data/status_shortcuts.nu
+var scriptStatusShortcutsNu string
+
+//go:embed data/status_state.nu
+var scriptStatusStateNu string
+
+//go:embed data/git_wrapper.nu
+var scriptGitWrapperNu string
+
+//go:embed data/status_shortcuts.ps1
+var scriptStatusShortcutsPwsh string
+
+//go:embed data/status_state.ps1
+var scriptStatusStatePwsh string
+
+//go:embed data/git_wrapper.ps1
+var scriptGitWrapperPwsh string
+
+//go:embed data/expand_key.sh
+var scriptExpandKey string
+
+//go:embed data/expand_key.fish
+var scriptExpandKeyFish string
+
+//go:embed data/completion.sh
+var scriptCompletion string
+
+//go:embed data/completion.fish
+var scriptCompletionFish string
+
 type scriptCollection struct {
-	statusShortcuts string
+	statusShortcuts string // status function for environment variable shortcuts
+	statusState     string // status function for state file shortcuts
 	gitWrapper      string
-	aliases         string
+	expandKey       string // functions expanding shortcuts in the command line, if supported
+	completion      string // loads the output of the completion command, if supported
+	exportFormat    string // format for exporting an environment variable
+	aliasFormat     string // format for defining an alias to a quoted command
+	quote           func(string) string
+	bindKey         func(key string) string // binds key to the expandKey functions
+	moduleFormat    string                  // format wrapping the whole script, if any
 }
 
 var bashCollection = scriptCollection{
 	statusShortcuts: scriptStatusShortcuts,
+	statusState:     scriptStatusState,
 	gitWrapper:      scriptGitWrapper,
-	aliases:         scriptAliases,
+	expandKey:       scriptExpandKey,
+	completion:      scriptCompletion,
+	exportFormat:    "export %s=%s\n",
+	aliasFormat:     "alias %s=%s\n",
+	quote:           quoteSh,
+	bindKey:         bindKeySh,
 }
 
 var fishCollection = scriptCollection{
 	statusShortcuts: scriptStatusShortcutsFish,
+	statusState:     scriptStatusStateFish,
 	gitWrapper:      scriptGitWrapperFish,
-	aliases:         scriptAliases,
+	expandKey:       scriptExpandKeyFish,
+	completion:      scriptCompletionFish,
+	exportFormat:    "set -gx %s %s\n",
+	aliasFormat:     "alias %s %s\n",
+	quote:           quoteFish,
+	bindKey:         bindKeyFish,
 }
 
-func (sc scriptCollection) Output(wrapGit, aliases bool) string {
+// nuCollection is for nushell, which has no equivalent to eval, so the script
+// is saved to a file to be sourced instead (see the init command).
+var nuCollection = scriptCollection{
+	statusShortcuts: scriptStatusShortcutsNu,
+	statusState:     scriptStatusStateNu,
+	gitWrapper:      scriptGitWrapperNu,
+	exportFormat:    "$env.%s = \"%s\"\n",
+	aliasFormat:     "alias %s = %s\n",
+	quote:           verbatim,
+}
+
+// pwshCollection is for PowerShell, where the script defines a module so that
+// it can be imported and removed as a whole. Aliases cannot take arguments, so
+// functions are defined in their place, replacing any built-in alias (e.g. gl).
+var pwshCollection = scriptCollection{
+	statusShortcuts: scriptStatusShortcutsPwsh,
+	statusState:     scriptStatusStatePwsh,
+	gitWrapper:      scriptGitWrapperPwsh,
+	exportFormat:    "$env:%s = '%s'\n",
+	aliasFormat:     "Remove-Alias -Name %[1]s -Scope Global -Force -ErrorAction Ignore\nfunction %[1]s { %[2]s @args }\n",
+	quote:           verbatim,
+	moduleFormat: `New-Module -Name scmpuff -ScriptBlock {
+%s
+Export-ModuleMember -Function *
+} | Import-Module -Global -Force`,
+}
+
+// outputOptions controls the contents of the initialization script.
+type outputOptions struct {
+	wrapGit     bool
+	aliases     []config.Alias
+	stateFile   bool   // keep shortcuts in a state file rather than environment variables
+	prefix      string // shortcut variable prefix, if not left to the environment
+	expandKey   string // key to expand shortcuts in the command line, if any
+	completions bool
+}
+
+// Output returns the initialization script.
+func (sc scriptCollection) Output(opts outputOptions) string {
 	var b strings.Builder
-	b.WriteString(sc.statusShortcuts)
-	if wrapGit {
+	if opts.prefix != "" {
+		// NOTE: prefix is validated to be a plain identifier, so needs no quoting
+		fmt.Fprintf(&b, sc.exportFormat, shortcuts.PrefixEnvVar, opts.prefix)
+	}
+	if opts.stateFile {
+		b.WriteString(sc.statusState)
+	} else {
+		b.WriteString(sc.statusShortcuts)
+	}
+	if opts.wrapGit {
 		b.WriteRune('\n')
 		b.WriteString(sc.gitWrapper)
 	}
-	if aliases {
+	if len(opts.aliases) > 0 {
+		b.WriteRune('\n')
+		b.WriteString(sc.aliasScript(opts.aliases))
+	}
+	if opts.expandKey != "" && sc.expandKey != "" {
 		b.WriteRune('\n')
-		b.WriteString(sc.aliases)
+		b.WriteString(sc.expandKey)
+		b.WriteRune('\n')
+		b.WriteString(sc.bindKey(opts.expandKey))
+	}
+	if opts.completions && sc.completion != "" {
+		b.WriteRune('\n')
+		b.WriteString(sc.completion)
+	}
+	if sc.moduleFormat != "" {
+		return fmt.Sprintf(sc.moduleFormat, b.String())
+	}
+	return b.String()
+}
+
+// aliasScript returns the script defining the given aliases.
+func (sc scriptCollection) aliasScript(aliases []config.Alias) string {
+	var b strings.Builder
+	for _, a := range aliases {
+		// NOTE: alias names are validated to be plain words, so need no quoting
+		fmt.Fprintf(&b, sc.aliasFormat, a.Name, sc.quote(a.Command))
 	}
 	return b.String()
 }
+
+// quoteSh returns s single-quoted for POSIX shells.
+func quoteSh(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// verbatim returns s as is, for shells where an alias is defined by code rather
+// than a string (e.g. nushell), so commands must already be written for them.
+func verbatim(s string) string {
+	return s
+}
+
+// quoteFish returns s single-quoted for fish, where backslashes and single
+// quotes are escaped within single quotes.
+func quoteFish(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(s) + "'"
+}
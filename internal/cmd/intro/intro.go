@@ -2,7 +2,10 @@ package intro
 
 import (
 	"fmt"
+	"io"
+	"text/tabwriter"
 
+	"github.com/mroth/scmpuff/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -12,12 +15,51 @@ func NewIntroCmd() *cobra.Command {
 		Use:   "intro",
 		Short: "Displays an introduction to scmpuff",
 		Long:  `Displays an introduction to using scmpuff.`,
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println(introText)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // silence usage-on-error after args processed
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			enabled, err := cfg.Bool("init.aliases")
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintln(w, introText)
+			fmt.Fprintln(w)
+			if !enabled {
+				fmt.Fprintln(w, aliasesDisabledText)
+				return nil
+			}
+			return writeAliases(w, cfg.Aliases())
 		},
 	}
 }
 
+// writeAliases writes the introduction to the configured aliases to w.
+func writeAliases(w io.Writer, aliases []config.Alias) error {
+	if len(aliases) == 0 {
+		_, err := fmt.Fprintln(w, aliasesNoneText)
+		return err
+	}
+
+	fmt.Fprintln(w, "scmpuff also defines a few handy aliases to save your fingers:")
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, a := range aliases {
+		fmt.Fprintf(tw, "  %s\t%s\n", a.Name, a.Command)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	_, err := fmt.Fprintln(w, aliasesConfigText)
+	return err
+}
+
 const introText = `Hello there!
 
 If you are just getting started, you probably want to make sure scmpuff is
@@ -51,7 +93,14 @@ You can now use these numbers in place of filenames when calling normal git
 commands, e.g. 'git add 2 3' or 'git checkout 1'.
 
 You can also use numeric ranges, e.g. 'git reset 2-4'. Ranges can even be mixed
-with normal numeric operands.
+with normal numeric operands.`
+
+const aliasesConfigText = `You can add your own aliases or change these with 'scmpuff config', e.g.
+'scmpuff config set alias.gdc "git diff --cached"', or disable one by setting
+it to "". (Aliases are not defined if scmpuff init is run with --aliases=false.)`
+
+const aliasesNoneText = `No aliases are configured, but you can add your own with 'scmpuff config',
+e.g. 'scmpuff config set alias.gdc "git diff --cached"'.`
 
-By default, scmpuff will also define a few handy shortcuts to save your fingers,
-e.g. 'ga', 'gd', 'gco'.  Check your aliases to see what they are.`
+const aliasesDisabledText = `Handy aliases such as 'ga' for 'git add' are disabled by the init.aliases
+setting, but can be enabled with 'scmpuff config set init.aliases true'.`
@@ -5,12 +5,16 @@ import (
 	"os"
 
 	goversion "github.com/caarlos0/go-version"
+	"github.com/mroth/scmpuff/internal/cmd/completion"
+	"github.com/mroth/scmpuff/internal/cmd/configs"
 	"github.com/mroth/scmpuff/internal/cmd/debug"
 	"github.com/mroth/scmpuff/internal/cmd/exec"
 	"github.com/mroth/scmpuff/internal/cmd/expand"
+	"github.com/mroth/scmpuff/internal/cmd/git"
 	"github.com/mroth/scmpuff/internal/cmd/inits"
 	"github.com/mroth/scmpuff/internal/cmd/intro"
 	"github.com/mroth/scmpuff/internal/cmd/status"
+	"github.com/mroth/scmpuff/internal/cmd/undo"
 	"github.com/spf13/cobra"
 )
 
@@ -25,8 +29,8 @@ If you are just getting started, try the intro!`,
 		Version: version,
 		Args:    cobra.NoArgs,
 
-		// disable default completions introduced in cobra v1.2.0, we will want to
-		// customize if we provide these in the future.
+		// disable the default completion command of cobra, in favor of our own
+		// which adds the completion of shortcuts in git commands.
 		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
 	}
 	rootCmd.SetVersionTemplate("{{.Version}}")
@@ -44,11 +48,16 @@ If you are just getting started, try the intro!`,
 	rootCmd.AddCommand(versionCmd)
 
 	rootCmd.AddCommand(intro.NewIntroCmd())
+	rootCmd.AddCommand(completion.NewCompletionCmd())
+	rootCmd.AddCommand(completion.NewShortcutsCmd())
+	rootCmd.AddCommand(configs.NewConfigCmd())
 	rootCmd.AddCommand(debug.NewDebugCmd())
 	rootCmd.AddCommand(exec.NewExecCmd())
 	rootCmd.AddCommand(expand.NewExpandCmd())
+	rootCmd.AddCommand(git.NewGitCmd())
 	rootCmd.AddCommand(inits.NewInitCmd())
 	rootCmd.AddCommand(status.NewStatusCmd())
+	rootCmd.AddCommand(undo.NewUndoCmd())
 
 	return rootCmd
 }
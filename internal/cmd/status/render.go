@@ -6,13 +6,15 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mroth/scmpuff/internal/gitstatus"
+	"github.com/mroth/scmpuff/internal/shortcuts"
 )
 
-// maxShortcutFiles is the maximum number of files that will be assigned
-// numeric shortcuts. This prevents the tab-delimited file list from exceeding
-// OS ARG_MAX limits when passed through shell functions.
+// maxShortcutFiles is the default maximum number of files that will be
+// assigned numeric shortcuts. This keeps the environment variables exported by
+// the shell functions for them within OS ARG_MAX limits.
 const maxShortcutFiles = 250
 
 // A Renderer formats git status information for display to the screen.
@@ -20,6 +22,8 @@ type Renderer struct {
 	branch       gitstatus.BranchInfo
 	groupedItems map[gitstatus.StatusGroup][]gitstatus.StatusItem // re-organize items by their StatusGroup
 	root, cwd    string                                           // root and cwd are used to calculate paths for display
+	quoting      gitstatus.PathQuoting                            // how unusual characters in displayed paths are escaped
+	maxFiles     int                                              // maximum number of files assigned shortcuts, 0 for no limit
 }
 
 // NewRenderer creates a new Renderer instance from the provided StatusInfo.
@@ -42,6 +46,7 @@ func NewRenderer(info *gitstatus.StatusInfo, root, cwd string) (*Renderer, error
 		groupedItems: groupedItems,
 		root:         root,
 		cwd:          cwd,
+		maxFiles:     maxShortcutFiles,
 	}, nil
 }
 
@@ -51,6 +56,47 @@ func (r *Renderer) Add(item gitstatus.StatusItem) {
 	r.groupedItems[group] = append(r.groupedItems[group], item)
 }
 
+// SetPathQuoting sets how unusual characters in displayed paths are escaped.
+// The default is gitstatus.QuoteUnprintable.
+//
+// This only affects the status display, the filelist always contains the raw
+// paths.
+func (r *Renderer) SetPathQuoting(quoting gitstatus.PathQuoting) {
+	r.quoting = quoting
+}
+
+// SetMaxShortcutFiles sets the maximum number of files assigned numeric
+// shortcuts, or no limit if n is 0. The default is maxShortcutFiles.
+//
+// Files beyond the limit are left out of both the display and the filelist.
+func (r *Renderer) SetMaxShortcutFiles(n int) {
+	r.maxFiles = n
+}
+
+// numShortcutFiles returns the number of files assigned numeric shortcuts.
+func (r *Renderer) numShortcutFiles() int {
+	if r.maxFiles == 0 {
+		return r.numItems()
+	}
+	return min(r.numItems(), r.maxFiles)
+}
+
+// hasNonASCIIPaths reports whether any path contains non-ASCII characters,
+// which are the only ones displayed differently with gitstatus.QuoteCStyle.
+func (r *Renderer) hasNonASCIIPaths() bool {
+	nonASCII := func(s string) bool {
+		return strings.ContainsFunc(s, func(r rune) bool { return r >= utf8.RuneSelf })
+	}
+	for _, items := range r.groupedItems {
+		for _, item := range items {
+			if nonASCII(item.Path) || nonASCII(item.OrigPath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // groupOrdering is the hardcoded list of the order StatusGroups should be displayed in
 var groupOrdering = []gitstatus.StatusGroup{
 	gitstatus.Staged,
@@ -120,7 +166,7 @@ func writeDisplayOutput(w io.Writer, r *Renderer) error {
 		items := r.groupedItems[group]
 
 		// How many shortcut slots are left before hitting the cap?
-		remaining := maxShortcutFiles - itemNumber + 1
+		remaining := r.numShortcutFiles() - itemNumber + 1
 		if len(items) == 0 || remaining <= 0 {
 			continue
 		}
@@ -134,9 +180,9 @@ func writeDisplayOutput(w io.Writer, r *Renderer) error {
 		b.WriteString(formatFooterForGroup(group))
 	}
 
-	if r.numItems() > maxShortcutFiles {
+	if r.numItems() > r.numShortcutFiles() {
 		fmt.Fprintf(b, "... showing %d of %d files (use git directly for bulk operations)\n",
-			maxShortcutFiles, r.numItems())
+			r.numShortcutFiles(), r.numItems())
 	}
 
 	// NOTE: Flush uses the errWriter pattern[1] and will return the first error
@@ -146,19 +192,59 @@ func writeDisplayOutput(w io.Writer, r *Renderer) error {
 	return b.Flush()
 }
 
+// WriteFilelist writes the machine readable list of files for environment
+// variable assignment in the scmpuff_status() shell functions to w, using
+// version 3 of the filelist protocol (see shortcuts.WriteFilelist).
+//
+// Unlike the tab-delimited parse data of Display (version 1) this is safe for
+// paths containing tabs, newlines, or any other character.
+func (r *Renderer) WriteFilelist(w io.Writer) error {
+	return shortcuts.WriteFilelist(w, r.Shortcuts())
+}
+
+// Shortcuts returns the Set of files assigned numeric shortcuts.
+func (r *Renderer) Shortcuts() shortcuts.Set {
+	return shortcuts.Set{Root: r.root, Paths: r.ShortcutPaths()}
+}
+
+// Fingerprint returns the Fingerprint of the files assigned numeric shortcuts.
+func (r *Renderer) Fingerprint() shortcuts.Fingerprint {
+	return r.Shortcuts().Fingerprint()
+}
+
 // formatParseData returns a machine readable string for environment variable parsing of file list in
 // the scmpuff_status() shell script.
 //
+// This is version 1 of the filelist protocol, which is tab-delimited and thus
+// cannot represent paths containing tabs or newlines, see WriteFilelist.
+func (r *Renderer) formatParseData() string {
+	return strings.Join(r.ShortcutPaths(), "\t")
+}
+
+// ShortcutPaths returns the absolute paths of the files assigned numeric
+// shortcuts, so that the path for shortcut N is at index N-1.
+//
 // Needs to be returned in same order that file lists are outputted to screen,
 // otherwise env vars won't match UI.
-func (r *Renderer) formatParseData() string {
+func (r *Renderer) ShortcutPaths() []string {
 	allItems := r.orderedItems()
-	limit := min(len(allItems), maxShortcutFiles)
+	limit := r.numShortcutFiles()
 	items := make([]string, limit)
 	for i := range limit {
 		items[i] = allItems[i].AbsPath(r.root)
 	}
-	return strings.Join(items, "\t")
+	return items
+}
+
+// Change returns the description of the change to the file at absPath, e.g.
+// "modified", and whether the file appears in the status at all.
+func (r *Renderer) Change(absPath string) (string, bool) {
+	for _, item := range r.orderedItems() {
+		if item.AbsPath(r.root) == absPath {
+			return item.ChangeType.Message(), true
+		}
+	}
+	return "", false
 }
 
 // formatBranchBanner formats the branch banner string to be used for printing.
@@ -207,11 +293,36 @@ func formatUpstreamDiffIndicator(b gitstatus.BranchInfo) string {
 
 func bannerChangeHeader() string {
 	return fmt.Sprintf(
-		"%s*%s => $e*\n%s",
-		DimForegroundColor.Sprint("["), DimForegroundColor.Sprint("]"), DimForegroundColor.Sprint("#"),
+		"%s*%s => $%s*\n%s",
+		DimForegroundColor.Sprint("["), DimForegroundColor.Sprint("]"), shortcuts.Prefix(), DimForegroundColor.Sprint("#"),
 	)
 }
 
+// summaryNames are the short names of status groups in a Summary.
+var summaryNames = map[gitstatus.StatusGroup]string{
+	gitstatus.Staged:    "staged",
+	gitstatus.Unmerged:  "unmerged",
+	gitstatus.Unstaged:  "unstaged",
+	gitstatus.Untracked: "untracked",
+}
+
+// Summary returns a one line summary of the number of files in each group,
+// for display after a command changed the status, e.g.
+//
+//	now: 3 staged, 2 unstaged
+func (r *Renderer) Summary() string {
+	var counts []string
+	for _, group := range groupOrdering {
+		if n := len(r.groupedItems[group]); n > 0 {
+			counts = append(counts, groupColors[group].Sprintf("%d %s", n, summaryNames[group]))
+		}
+	}
+	if len(counts) == 0 {
+		return "now: " + GreenColor.Sprint("clean")
+	}
+	return "now: " + strings.Join(counts, ", ")
+}
+
 // bannerNoChanges returns the no changes message when working directory is clean
 func bannerNoChanges() string {
 	return GreenColor.Sprint("No changes (working directory clean)")
@@ -256,7 +367,7 @@ func (r *Renderer) formatStatusItemDisplay(item gitstatus.StatusItem, displayNum
 		padding = " "
 	}
 
-	itemDisplayPath := item.DisplayPath(r.root, r.cwd)
+	itemDisplayPath := item.DisplayPath(r.root, r.cwd, r.quoting)
 
 	// Message padding:
 	//  - Unmerged change msgs: leftpad to 15 character width
@@ -7,10 +7,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/fatih/color"
 	"github.com/mroth/scmpuff/internal/gitstatus"
+	"github.com/mroth/scmpuff/internal/shortcuts"
 )
 
 var (
@@ -32,6 +34,7 @@ func TestRenderer_Display(t *testing.T) {
 		name      string
 		info      gitstatus.StatusInfo
 		root, cwd string
+		quoting   gitstatus.PathQuoting
 	}{
 		{
 			// Replaces feature test: command_status.feature / Scenario: Banner shows no changes when in an unchanged git repo
@@ -198,6 +201,35 @@ func TestRenderer_Display(t *testing.T) {
 			root: "/path/to/repo",
 			cwd:  "/path/to/repo",
 		},
+		{
+			// filenames containing control characters and terminal escape
+			// sequences must be escaped for display, but not in the parsedata
+			name: "control_characters",
+			info: gitstatus.StatusInfo{
+				Branch: gitstatus.BranchInfo{Name: "main"},
+				Items: []gitstatus.StatusItem{
+					{ChangeType: gitstatus.ChangeStagedRenamed, Path: "new\tname.txt", OrigPath: "old\nname.txt"},
+					{ChangeType: gitstatus.ChangeUntracked, Path: "\x1b[2J\x1b[31mgotcha.txt"},
+					{ChangeType: gitstatus.ChangeUntracked, Path: "修改后的文件.php"},
+				},
+			},
+			root: "/repo",
+			cwd:  "/repo",
+		},
+		{
+			name: "quote_path",
+			info: gitstatus.StatusInfo{
+				Branch: gitstatus.BranchInfo{Name: "main"},
+				Items: []gitstatus.StatusItem{
+					{ChangeType: gitstatus.ChangeUnstagedModified, Path: "修改后的文件.php"},
+					{ChangeType: gitstatus.ChangeUntracked, Path: "tab\there.txt"},
+					{ChangeType: gitstatus.ChangeUntracked, Path: "plain.txt"},
+				},
+			},
+			root:    "/repo",
+			cwd:     "/repo",
+			quoting: gitstatus.QuoteCStyle,
+		},
 		{
 			name: "truncated",
 			info: func() gitstatus.StatusInfo {
@@ -240,6 +272,7 @@ func TestRenderer_Display(t *testing.T) {
 					if err != nil {
 						t.Fatalf("NewRenderer() error: %v", err)
 					}
+					renderer.SetPathQuoting(tc.quoting)
 
 					var buf bytes.Buffer
 					err = renderer.Display(&buf, oc.includeParseData, oc.includeStatusOutput)
@@ -298,3 +331,120 @@ func goldenClobberFiles() {
 		log.Printf("♻️ removed golden file %s", file)
 	}
 }
+
+func TestRenderer_WriteFilelist(t *testing.T) {
+	info := gitstatus.StatusInfo{
+		Items: []gitstatus.StatusItem{
+			{ChangeType: gitstatus.ChangeUntracked, Path: "tab\there.txt"},
+			{ChangeType: gitstatus.ChangeUntracked, Path: "new\nline.txt"},
+			{ChangeType: gitstatus.ChangeStagedNewFile, Path: "staged.txt"},
+		},
+	}
+	renderer, err := NewRenderer(&info, "/repo", "/repo")
+	if err != nil {
+		t.Fatalf("NewRenderer() error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.WriteFilelist(&buf); err != nil {
+		t.Fatalf("WriteFilelist() error: %v", err)
+	}
+
+	// header, fingerprint, then files in display order (staged first), each
+	// NUL terminated
+	paths := []string{"/repo/staged.txt", "/repo/tab\there.txt", "/repo/new\nline.txt"}
+	fingerprint := shortcuts.NewFingerprint("/repo", paths)
+	if got := renderer.Fingerprint(); got != fingerprint {
+		t.Errorf("Fingerprint() = %v, want %v", got, fingerprint)
+	}
+	want := shortcuts.FilelistHeader + "\x00" + fingerprint.String() + "\x00" + strings.Join(paths, "\x00") + "\x00"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteFilelist() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderer_Summary(t *testing.T) {
+	color.NoColor = true
+	tests := []struct {
+		name  string
+		items []gitstatus.StatusItem
+		want  string
+	}{
+		{name: "clean", want: "now: clean"},
+		{
+			name: "groups in display order",
+			items: []gitstatus.StatusItem{
+				{ChangeType: gitstatus.ChangeUntracked, Path: "c.txt"},
+				{ChangeType: gitstatus.ChangeUnstagedModified, Path: "b.txt"},
+				{ChangeType: gitstatus.ChangeStagedNewFile, Path: "a.txt"},
+				{ChangeType: gitstatus.ChangeStagedModified, Path: "d.txt"},
+				{ChangeType: gitstatus.ChangeUnmergedAddedBoth, Path: "e.txt"},
+			},
+			want: "now: 2 staged, 1 unmerged, 1 unstaged, 1 untracked",
+		},
+		{
+			name:  "only unstaged",
+			items: []gitstatus.StatusItem{{ChangeType: gitstatus.ChangeUnstagedDeleted, Path: "a.txt"}},
+			want:  "now: 1 unstaged",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer, err := NewRenderer(&gitstatus.StatusInfo{Items: tt.items}, "/repo", "/repo")
+			if err != nil {
+				t.Fatalf("NewRenderer() error: %v", err)
+			}
+			if got := renderer.Summary(); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_MaxShortcutFiles(t *testing.T) {
+	color.NoColor = true
+	var info gitstatus.StatusInfo
+	for i := range maxShortcutFiles + 50 {
+		info.Items = append(info.Items, gitstatus.StatusItem{
+			ChangeType: gitstatus.ChangeUntracked,
+			Path:       fmt.Sprintf("f%03d.txt", i),
+		})
+	}
+
+	tests := []struct {
+		name     string
+		maxFiles int
+		want     int
+		footer   bool
+	}{
+		{name: "default", maxFiles: maxShortcutFiles, want: maxShortcutFiles, footer: true},
+		{name: "lower", maxFiles: 10, want: 10, footer: true},
+		{name: "no limit", maxFiles: 0, want: maxShortcutFiles + 50, footer: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer, err := NewRenderer(&info, "/repo", "/repo")
+			if err != nil {
+				t.Fatalf("NewRenderer() error: %v", err)
+			}
+			renderer.SetMaxShortcutFiles(tt.maxFiles)
+
+			if got := len(renderer.ShortcutPaths()); got != tt.want {
+				t.Errorf("len(ShortcutPaths()) = %d, want %d", got, tt.want)
+			}
+			var buf bytes.Buffer
+			if err := renderer.Display(&buf, false, true); err != nil {
+				t.Fatalf("Display() error: %v", err)
+			}
+			if got := strings.Contains(buf.String(), fmt.Sprintf("[%d]", tt.want)); !got {
+				t.Errorf("Display() is missing the last shortcut [%d]", tt.want)
+			}
+			if got := strings.Contains(buf.String(), fmt.Sprintf("[%d]", tt.want+1)); got {
+				t.Errorf("Display() has shortcut [%d] beyond the limit", tt.want+1)
+			}
+			if got := strings.Contains(buf.String(), "... showing"); got != tt.footer {
+				t.Errorf("Display() footer = %v, want %v", got, tt.footer)
+			}
+		})
+	}
+}
@@ -4,18 +4,24 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
+	"github.com/mroth/scmpuff/internal/config"
+	"github.com/mroth/scmpuff/internal/gitstatus"
 	"github.com/mroth/scmpuff/internal/gitstatus/porcelainv2"
+	"github.com/mroth/scmpuff/internal/shortcuts"
 	"github.com/spf13/cobra"
 )
 
 var optsFilelist bool
+var optsFilelistOut string
 var optsDisplay bool
+var optsQuotePath bool
 
 // NewStatusCmd creates and returns the status command
 func NewStatusCmd() *cobra.Command {
@@ -28,6 +34,12 @@ contain the path of each affected file.
 
 The output is prettier and more concise than standard 'git status'.
 
+Displayed paths containing control or other non-printable characters are quoted
+and escaped in C-style, as git does. With --quote-path, all non-ASCII characters
+are escaped as well (like git's core.quotePath), which can also be enabled with
+the status.quotePath setting (see 'scmpuff config'). The exported variables
+always contain the actual paths.
+
 In most cases, you won't want to call this directly, but rather will be using
 the exported shell-function 'scmpuff_status', which wraps this command and also
 sets the environment variables for your shell. (For more information on this,
@@ -36,21 +48,7 @@ see 'scmpuff init'.)
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true // silence usage-on-error after args processed
 
-			// Determine color output based on the user's terminal, not our stdout.
-			//
-			// stdout is always a pipe when invoked via the scmpuff_status() shell
-			// wrapper (which captures output in a subshell), so we cannot rely on
-			// fatih/color's default TTY detection against stdout. Instead, check
-			// stderr (which remains connected to the user's terminal) and honor
-			// the NO_COLOR convention (https://no-color.org/).
-			switch {
-			case os.Getenv("NO_COLOR") != "":
-				color.NoColor = true
-			case isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd()):
-				color.NoColor = false
-			default:
-				color.NoColor = true
-			}
+			setColorMode()
 
 			// Obtain the current working directory (needed to determine git root and relative paths)
 			wd, err := os.Getwd()
@@ -106,12 +104,23 @@ You can file the bug at: https://github.com/mroth/scmpuff/issues/`, err)
 			if err != nil {
 				return fmt.Errorf("fatal: failed to create status renderer: %w", err)
 			}
-
-			if err := renderer.Display(os.Stdout, optsFilelist, optsDisplay); err != nil {
-				return fmt.Errorf("fatal: failed to render status: %w", err)
+			renderer.SetMaxShortcutFiles(shortcutLimit())
+			// The setting only makes a difference to non-ASCII paths, so
+			// the configuration is not loaded on every run just for it.
+			if !cmd.Flags().Changed("quote-path") && renderer.hasNonASCIIPaths() {
+				cfg, err := config.Load()
+				if err != nil {
+					return err
+				}
+				if optsQuotePath, err = cfg.Bool("status.quotePath"); err != nil {
+					return err
+				}
+			}
+			if optsQuotePath {
+				renderer.SetPathQuoting(gitstatus.QuoteCStyle)
 			}
 
-			return nil
+			return publish(os.Stdout, renderer, optsFilelistOut, optsFilelist, optsDisplay)
 		},
 	}
 
@@ -122,6 +131,15 @@ You can file the bug at: https://github.com/mroth/scmpuff/issues/`, err)
 		"include machine-parseable filelist",
 	)
 
+	// --filelist-out
+	// NUL-delimited filelist protocol (version 2), written to a separate file
+	// so it can be safely read by the shell functions, see WriteFilelist.
+	statusCmd.Flags().StringVar(
+		&optsFilelistOut,
+		"filelist-out", "",
+		"write NUL-delimited machine-parseable filelist to `path`",
+	)
+
 	// --display
 	// allow normal display to be disabled, not really useful unless you know you
 	// JUST want the machine parseable file-list for some reason.
@@ -131,9 +149,155 @@ You can file the bug at: https://github.com/mroth/scmpuff/issues/`, err)
 		"displays the formatted status output",
 	)
 
+	// --quote-path
+	// Like git's core.quotePath, escape all non-ASCII characters in displayed
+	// paths. Control characters are always escaped regardless.
+	statusCmd.Flags().BoolVar(
+		&optsQuotePath,
+		"quote-path", false,
+		"display paths quoted in C-style, escaping all non-ASCII characters",
+	)
+
 	return statusCmd
 }
 
+// setColorMode determines color output based on the user's terminal, not our
+// stdout.
+//
+// stdout is always a pipe when invoked via the scmpuff_status() shell wrapper
+// (which captures output in a subshell), so we cannot rely on fatih/color's
+// default TTY detection against stdout. Instead, check stderr (which remains
+// connected to the user's terminal) and honor the NO_COLOR convention
+// (https://no-color.org/).
+func setColorMode() {
+	switch {
+	case os.Getenv("NO_COLOR") != "":
+		color.NoColor = true
+	case isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd()):
+		color.NoColor = false
+	default:
+		color.NoColor = true
+	}
+}
+
+// publish saves the shortcuts of renderer for its repository when they may be
+// restored later, writes its filelist to the file at filelistOut (if not
+// empty), and displays it to w.
+func publish(w io.Writer, renderer *Renderer, filelistOut string, filelist, display bool) error {
+	// Remember the shortcuts for this repository, so they can be restored
+	// after working in another one (see shortcuts.NewResolver). That is only
+	// ever done for the state file, or for shortcuts the shell functions export
+	// from the filelist, so nothing is written by a plain status.
+	if filelistOut != "" || os.Getenv(shortcuts.StoreEnvVar) == "file" {
+		if err := shortcuts.Save(renderer.Shortcuts()); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+
+	if filelistOut != "" {
+		if err := writeFilelistFile(filelistOut, renderer); err != nil {
+			return fmt.Errorf("fatal: failed to write filelist: %w", err)
+		}
+	}
+
+	if err := renderer.Display(w, filelist, display); err != nil {
+		return fmt.Errorf("fatal: failed to render status: %w", err)
+	}
+	return nil
+}
+
+// Refresh updates the shortcuts to the current status, after another command
+// may have changed it. The numbered files are saved for the repository, and
+// written to the file at filelistOut if it is not empty, so that the shell can
+// assign them to shortcut variables.
+//
+// Depending on mode, the status is also displayed to w as the status command
+// does by default, or summarized on a single line (see Renderer.Summary), as
+// configured by cfg.
+func Refresh(w io.Writer, cfg *config.Config, filelistOut string, mode config.RefreshMode) error {
+	setColorMode()
+	renderer, err := Load()
+	if err != nil {
+		return err
+	}
+	quotePath, err := cfg.Bool("status.quotePath")
+	if err != nil {
+		return err
+	}
+	if quotePath {
+		renderer.SetPathQuoting(gitstatus.QuoteCStyle)
+	}
+	if err := publish(w, renderer, filelistOut, false, mode == config.RefreshStatus); err != nil {
+		return err
+	}
+	if mode == config.RefreshSummary {
+		_, err := fmt.Fprintln(w, renderer.Summary())
+		return err
+	}
+	return nil
+}
+
+// Load runs git status for the repository containing the current working
+// directory and returns a Renderer for the result, so that the current numbered
+// list of files can be obtained outside of the status command.
+func Load() (*Renderer, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve current working directory: %w", err)
+	}
+	root, err := gitProjectRoot(wd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine git project root: %w", err)
+	}
+	status, err := gitStatusOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error running git status command: %w", err)
+	}
+	info, err := porcelainv2.Process(status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process git status output: %w", err)
+	}
+	renderer, err := NewRenderer(info, root, wd)
+	if err != nil {
+		return nil, err
+	}
+	renderer.SetMaxShortcutFiles(shortcutLimit())
+	return renderer, nil
+}
+
+// shortcutLimit returns the maximum number of files assigned numeric shortcuts.
+// The default limit keeps the exported environment variables in check, so
+// there is none when the shortcuts are kept in the state file instead.
+func shortcutLimit() int {
+	if os.Getenv(shortcuts.StoreEnvVar) == "file" {
+		return 0
+	}
+	return maxShortcutFiles
+}
+
+// CurrentShortcuts returns the Set of files that would currently be assigned
+// numeric shortcuts by the status command, see Load. It is a shortcuts.Loader.
+func CurrentShortcuts() (shortcuts.Set, error) {
+	renderer, err := Load()
+	if err != nil {
+		return shortcuts.Set{}, err
+	}
+	return renderer.Shortcuts(), nil
+}
+
+// writeFilelistFile writes the filelist for renderer to the file at path.
+func writeFilelistFile(path string, renderer *Renderer) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := renderer.WriteFilelist(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // Runs `git status --porcelain=v2 -b -z` and returns the results.
 //
 // The -z flag uses NUL (ASCII 0) as line terminators instead of newlines,
@@ -37,6 +37,10 @@ func TestScripts(t *testing.T) {
 			e.Setenv("GIT_CONFIG_NOSYSTEM", "1")
 			e.Setenv("GIT_TERMINAL_PROMPT", "0")
 
+			// Keep saved shortcuts within the test work directory.
+			e.Setenv("XDG_STATE_HOME", e.WorkDir+"/.state")
+			e.Setenv("XDG_CONFIG_HOME", e.WorkDir+"/.config")
+
 			e.Setenv("GIT_AUTHOR_NAME", "SCM Puff")
 			e.Setenv("GIT_AUTHOR_EMAIL", "scmpuff@example.com")
 			e.Setenv("GIT_COMMITTER_NAME", "SCM Puff")
@@ -0,0 +1,131 @@
+package undo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/mroth/scmpuff/internal/gitstatus"
+	"github.com/mroth/scmpuff/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// NewUndoCmd creates and returns the undo command
+func NewUndoCmd() *cobra.Command {
+	undoCmd := &cobra.Command{
+		Use:   "undo [<snapshot> [<paths>...]]",
+		Short: "Restore files from safety snapshots",
+		Long: `Restores files from the safety snapshots taken automatically before running
+destructive commands via scmpuff (e.g. 'git checkout 1' or 'git restore 2-4').
+
+With no arguments, lists the recent snapshots for the current repository, most
+recent first. Given a snapshot number, restores all of the files it contains,
+or only the given paths. A directory targeted by a command is snapshotted as
+the files git lists within it, so ignored files are not recoverable.
+
+The current contents of any file being overwritten are themselves snapshotted
+first, so an undo can be undone.
+
+Snapshot contents are kept as unreferenced git objects, so they are only
+recoverable until they are removed by 'git gc' (two weeks by default).`,
+		Example: `$ scmpuff undo
+$ scmpuff undo 1
+$ scmpuff undo 2 src/main.go`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true // silence usage-on-error after args processed
+
+			wd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("fatal: failed to retrieve current working directory: %w", err)
+			}
+			journal, err := snapshot.Open(wd)
+			if err != nil {
+				return err
+			}
+			snapshots, err := journal.List()
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				return listSnapshots(cmd.OutOrStdout(), snapshots)
+			}
+
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 || n > len(snapshots) {
+				return fmt.Errorf("no such snapshot %q (see 'scmpuff undo' for a list)", args[0])
+			}
+			files, err := selectFiles(snapshots[n-1], journal.Root(), wd, args[1:])
+			if err != nil {
+				return err
+			}
+			return restore(cmd.OutOrStdout(), journal, files)
+		},
+	}
+
+	return undoCmd
+}
+
+// listSnapshots writes a numbered list of snapshots and their files to w.
+func listSnapshots(w io.Writer, snapshots []snapshot.Snapshot) error {
+	if len(snapshots) == 0 {
+		_, err := fmt.Fprintln(w, "No snapshots recorded for this repository.")
+		return err
+	}
+	for i, snap := range snapshots {
+		fmt.Fprintf(w, "[%d] %s  %s\n", i+1, snap.Time.Local().Format("2006-01-02 15:04:05"), snap.Command)
+		for _, f := range snap.Files {
+			if _, err := fmt.Fprintf(w, "      %s\n", gitstatus.QuotePath(f.Path, gitstatus.QuoteUnprintable)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectFiles returns the files from snap matching paths, which are relative
+// to the working directory wd. If no paths are given, all files are selected.
+func selectFiles(snap snapshot.Snapshot, root, wd string, paths []string) ([]snapshot.File, error) {
+	if len(paths) == 0 {
+		return snap.Files, nil
+	}
+
+	var selected []snapshot.File
+	for _, p := range paths {
+		relPath, err := filepath.Rel(root, filepath.Join(wd, p))
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine repository path for %s: %w", p, err)
+		}
+		i := slices.IndexFunc(snap.Files, func(f snapshot.File) bool {
+			return f.Path == filepath.ToSlash(relPath)
+		})
+		if i == -1 {
+			return nil, fmt.Errorf("%s is not in the snapshot", p)
+		}
+		selected = append(selected, snap.Files[i])
+	}
+	return selected, nil
+}
+
+// restore snapshots the current contents of files before restoring their
+// backed up contents, reporting each file restored to w.
+func restore(w io.Writer, journal *snapshot.Journal, files []snapshot.File) error {
+	var current []string
+	for _, f := range files {
+		current = append(current, filepath.Join(journal.Root(), filepath.FromSlash(f.Path)))
+	}
+	if _, err := journal.Save("scmpuff undo", current); err != nil {
+		return fmt.Errorf("failed to snapshot current contents, not restoring: %w", err)
+	}
+
+	for _, f := range files {
+		if _, err := journal.Restore(f); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Restored %s\n", gitstatus.QuotePath(f.Path, gitstatus.QuoteUnprintable))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// An Alias is a short shell alias for a command, defined by the shell
+// integration.
+type Alias struct {
+	Name    string
+	Command string
+}
+
+// DefaultAliases are the aliases defined unless configured otherwise.
+var DefaultAliases = []Alias{
+	{Name: "gs", Command: "scmpuff_status"},
+	{Name: "ga", Command: "git add"},
+	{Name: "gd", Command: "git diff"},
+	{Name: "gl", Command: "git log"},
+	{Name: "gco", Command: "git checkout"},
+	{Name: "grs", Command: "git reset"},
+}
+
+// aliasKey is the key prefix for aliases, where the final key component is
+// the alias name, e.g. in the config file:
+//
+//	[alias]
+//	gdc = "git diff --cached"
+//	gco = ""  # disables a default alias
+const aliasKey = "alias."
+
+// aliasNameRegexp matches the alias names that are safe to define in all
+// supported shells, and can be set in git config, where the name is a variable
+// name (which cannot contain "_").
+var aliasNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+
+// ValidAliasName reports whether name may be used as an alias name.
+func ValidAliasName(name string) bool {
+	return aliasNameRegexp.MatchString(name)
+}
+
+// Aliases returns the aliases in effect: the DefaultAliases, replaced or
+// extended by any configured "alias.<name>" settings, followed by the added
+// aliases in order of name. An alias configured with an empty command is
+// removed.
+//
+// Alias names are matched without regard to case, as git config keys are, but
+// keep the case they were last configured with.
+func (c *Config) Aliases() []Alias {
+	aliases := slices.Clone(DefaultAliases)
+	var added []Alias
+	for _, e := range c.entries {
+		name, ok := cutPrefixFold(e.key, aliasKey)
+		if !ok || !ValidAliasName(name) {
+			continue
+		}
+		alias := Alias{Name: name, Command: e.value}
+		match := func(a Alias) bool { return strings.EqualFold(a.Name, name) }
+		if i := slices.IndexFunc(aliases, match); i != -1 {
+			aliases[i] = alias
+		} else if i := slices.IndexFunc(added, match); i != -1 {
+			added[i] = alias
+		} else {
+			added = append(added, alias)
+		}
+	}
+
+	slices.SortFunc(added, func(a, b Alias) int { return strings.Compare(a.Name, b.Name) })
+	aliases = append(aliases, added...)
+	return slices.DeleteFunc(aliases, func(a Alias) bool { return a.Command == "" })
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAliases(t *testing.T) {
+	dir := setupGitConfig(t, "[scmpuff \"alias\"]\n\tgdc = git diff --cached\n\tgl = \n")
+	writeUserConfig(t, dir, "[alias]\ngst = \"scmpuff_status\"\nGDC = \"git diff --staged\"\ngco = \"git switch\"\n\"bad name\" = \"x\"\n")
+
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Alias{
+		{Name: "gs", Command: "scmpuff_status"},
+		{Name: "ga", Command: "git add"},
+		{Name: "gd", Command: "git diff"},
+		{Name: "gco", Command: "git switch"},
+		{Name: "grs", Command: "git reset"},
+		{Name: "GDC", Command: "git diff --staged"},
+		{Name: "gst", Command: "scmpuff_status"},
+	}
+	if diff := cmp.Diff(want, c.Aliases()); diff != "" {
+		t.Errorf("Aliases() mismatch (-want +got):\n%s", diff)
+	}
+
+	v, err := c.Get("alias.gl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Value != "" || v.Source != SourceGlobal {
+		t.Errorf("Get(alias.gl) = %q from %v, want disabled from %v", v.Value, v.Source, SourceGlobal)
+	}
+	for _, name := range []string{"bad name", "my_x", "_x"} {
+		if _, err := c.Get("alias." + name); err == nil {
+			t.Errorf("Get() of invalid alias name %q, expected error", name)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+// Package config loads user configuration for scmpuff.
+//
+// Settings are read from several layers, each overriding those before it:
+//
+//  1. built-in defaults
+//  2. global git config (~/.gitconfig), under the "scmpuff" section
+//  3. the user config file, $XDG_CONFIG_HOME/scmpuff/config.toml
+//  4. repository git config (.git/config), under the "scmpuff" section
+//  5. environment variables, for the settings that have one
+//
+// Command line flags take precedence over all of these, and are applied by
+// the commands themselves.
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Source identifies the layer a configured value came from.
+type Source int
+
+// Source constants, in increasing order of precedence.
+const (
+	SourceDefault Source = iota
+	SourceGlobal         // global or system git config
+	SourceUser           // user config file
+	SourceRepo           // repository git config
+	SourceEnv            // environment variable
+	SourceFlag           // command line flag
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceGlobal:
+		return "global"
+	case SourceUser:
+		return "user"
+	case SourceRepo:
+		return "repo"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	default:
+		return fmt.Sprintf("Source(%d)", int(s))
+	}
+}
+
+// A Value is the effective value of a setting.
+type Value struct {
+	Key    string
+	Value  string
+	Source Source
+	Origin string // the file or environment variable it was set in, if any
+}
+
+// Location describes where v was set, e.g. "user:/home/me/.config/...".
+func (v Value) Location() string {
+	if v.Origin == "" {
+		return v.Source.String()
+	}
+	return v.Source.String() + ":" + v.Origin
+}
+
+// Config is the configuration loaded from all layers.
+type Config struct {
+	entries []sourcedEntry // in increasing order of precedence
+}
+
+type configEntry struct {
+	key, value string
+}
+
+type sourcedEntry struct {
+	configEntry
+	source Source
+	origin string
+}
+
+// Load reads the configuration from git config, the user config file and the
+// environment.
+func Load() (*Config, error) {
+	var c Config
+
+	gitEntries, err := gitConfigRegexp(`^scmpuff\.`)
+	if err != nil {
+		return nil, err
+	}
+	c.entries = append(c.entries, gitEntries...)
+
+	path, err := UserConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	fileEntries, err := parseTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	for _, e := range fileEntries {
+		c.entries = append(c.entries, sourcedEntry{configEntry: e, source: SourceUser, origin: path})
+	}
+
+	// An invalid environment variable (e.g. SCMPUFF_DRY_RUN=yes) is only
+	// warned about, as it would otherwise break every command until unset.
+	for _, s := range Settings {
+		value := os.Getenv(s.EnvVar) // always empty without an EnvVar
+		if value == "" {
+			continue
+		}
+		if err := s.validate(value); err != nil {
+			fmt.Fprintf(os.Stderr, "scmpuff: warning: ignoring %s: %v\n", s.EnvVar, err)
+			continue
+		}
+		c.entries = append(c.entries, sourcedEntry{configEntry: configEntry{key: s.Key, value: value}, source: SourceEnv, origin: s.EnvVar})
+	}
+
+	slices.SortStableFunc(c.entries, func(a, b sourcedEntry) int {
+		return cmp.Compare(a.source, b.source)
+	})
+	return &c, nil
+}
+
+// UserConfigPath returns the path of the user config file, which need not
+// exist: $XDG_CONFIG_HOME/scmpuff/config.toml, or ~/.config/scmpuff/config.toml
+// if XDG_CONFIG_HOME is unset.
+func UserConfigPath() (string, error) {
+	// per the XDG Base Directory spec, relative paths must be ignored
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "scmpuff", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config file: %w", err)
+	}
+	return filepath.Join(home, ".config", "scmpuff", "config.toml"), nil
+}
+
+// Get returns the effective value of the setting key, which must be one of
+// Settings or a no-expand rule (e.g. "noexpand.log").
+//
+// For a no-expand rule, which accumulates options across all layers, the
+// options are joined by spaces and reported as coming from the highest layer
+// that sets any of them.
+func (c *Config) Get(key string) (Value, error) {
+	s, ok := lookupSetting(key)
+	if !ok {
+		return Value{}, fmt.Errorf("unknown setting %q", key)
+	}
+
+	v := Value{Key: s.Key, Value: s.Default, Source: SourceDefault}
+	var accumulated []string
+	for _, e := range c.entries {
+		if !strings.EqualFold(e.key, s.Key) {
+			continue
+		}
+		v.Value, v.Source, v.Origin = e.value, e.source, e.origin
+		accumulated = append(accumulated, strings.Fields(e.value)...)
+	}
+	if _, ok := cutPrefixFold(s.Key, noExpandKey); ok {
+		v.Value = strings.Join(accumulated, " ")
+		return v, nil
+	}
+	if err := s.validate(v.Value); err != nil {
+		return Value{}, fmt.Errorf("%s: %w", v.Location(), err)
+	}
+	return v, nil
+}
+
+// String returns the effective value of the setting key.
+func (c *Config) String(key string) (string, error) {
+	v, err := c.Get(key)
+	return v.Value, err
+}
+
+// Bool returns the effective value of the boolean setting key.
+func (c *Config) Bool(key string) (bool, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(v.Value)
+}
+
+// Int returns the effective value of the integer setting key.
+func (c *Config) Int(key string) (int, error) {
+	v, err := c.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(v.Value)
+}
+
+// List returns the effective values of all Settings, followed by any
+// configured no-expand rules and wrapper rules in order of subcommand, and
+// then all default and configured aliases (including those disabled).
+func (c *Config) List() ([]Value, error) {
+	var values []Value
+	for _, s := range Settings {
+		v, err := c.Get(s.Key)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	rules := c.NoExpandRules()
+	for _, subcommand := range slices.Sorted(maps.Keys(rules)) {
+		v, err := c.Get(noExpandKey + subcommand)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	var wrapped []string
+	for _, e := range c.entries {
+		if subcommand, ok := cutPrefixFold(e.key, wrapperKey); ok {
+			wrapped = append(wrapped, strings.ToLower(subcommand))
+		}
+	}
+	slices.Sort(wrapped)
+	for _, subcommand := range slices.Compact(wrapped) {
+		v, err := c.Get(wrapperKey + subcommand)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	var aliases []string
+	for _, a := range DefaultAliases {
+		aliases = append(aliases, a.Name)
+	}
+	for _, e := range c.entries {
+		name, ok := cutPrefixFold(e.key, aliasKey)
+		if ok && ValidAliasName(name) && !slices.ContainsFunc(aliases, func(a string) bool { return strings.EqualFold(a, name) }) {
+			aliases = append(aliases, name)
+		}
+	}
+	for _, name := range aliases {
+		v, err := c.Get(aliasKey + name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// NoExpandRules returns the user-defined no-expand rules, mapping a git
+// subcommand to additional options whose value must not be expanded as a file
+// shortcut.
+//
+// Each value is a whitespace separated list of options, and a key may be set
+// multiple times (e.g. with `git config --add`, or in different layers) to
+// accumulate options.
+func (c *Config) NoExpandRules() map[string][]string {
+	rules := make(map[string][]string)
+	for _, e := range c.entries {
+		subcommand, ok := cutPrefixFold(e.key, noExpandKey)
+		if !ok || subcommand == "" {
+			continue
+		}
+		subcommand = strings.ToLower(subcommand)
+		rules[subcommand] = append(rules[subcommand], strings.Fields(e.value)...)
+	}
+	return rules
+}
+
+// DefaultConfirmThreshold is the number of file shortcuts a destructive
+// command (e.g. "git checkout 1-30") may target before confirmation is
+// required, unless configured otherwise with the "confirmThreshold" setting. A
+// negative value disables confirmation entirely.
+const DefaultConfirmThreshold = 5
+
+// SetUser sets key to value in the user config file, creating it if needed,
+// and returns the path of the file.
+func SetUser(key, value string) (string, error) {
+	s, err := settingFor(key, value)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := UserConfigPath()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	updated, err := setTOML(string(data), s.Key, value, s.Kind)
+	if err != nil {
+		return "", fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	return path, nil
+}
+
+// SetRepo sets key to value in the git config of the current repository.
+func SetRepo(key, value string) error {
+	s, err := settingFor(key, value)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "config", "--local", "scmpuff."+s.Key, value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write git config: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// settingFor returns the Setting for key, after validating value for it.
+func settingFor(key, value string) (Setting, error) {
+	s, ok := lookupSetting(key)
+	if !ok {
+		return Setting{}, fmt.Errorf("unknown setting %q", key)
+	}
+	return s, s.validate(value)
+}
+
+// gitConfigRegexp returns all git config entries whose key matches pattern, in
+// the order git reports them, with the "scmpuff." prefix removed from keys.
+//
+// A missing git binary or an absence of matching keys is not an error, and
+// simply results in no entries.
+func gitConfigRegexp(pattern string) ([]sourcedEntry, error) {
+	out, err := exec.Command("git", "config", "-z", "--show-scope", "--show-origin", "--get-regexp", pattern).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 129 {
+			// git before 2.26 has no --show-scope, and exits with a usage
+			// error, so each scope is read on its own instead
+			return gitConfigRegexpByScope(pattern)
+		}
+		return nil, gitConfigError(err)
+	}
+
+	// With -z, each entry is the scope, origin, and "key\nvalue", each
+	// terminated by NUL. A key set with no value (e.g. "[scmpuff] foo") has no
+	// newline at all.
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	var entries []sourcedEntry
+	for i := 0; i+2 < len(fields); i += 3 {
+		source := SourceRepo
+		if scope := string(fields[i]); scope == "global" || scope == "system" {
+			source = SourceGlobal
+		}
+		entries = append(entries, gitConfigEntry(source, fields[i+1], fields[i+2]))
+	}
+	return entries, nil
+}
+
+// gitConfigRegexpByScope is gitConfigRegexp for git versions without
+// --show-scope, reading the system, global and repository scopes in turn.
+func gitConfigRegexpByScope(pattern string) ([]sourcedEntry, error) {
+	scopes := []struct {
+		flag   string
+		source Source
+	}{
+		{"--system", SourceGlobal},
+		{"--global", SourceGlobal},
+		{"--local", SourceRepo},
+	}
+	var entries []sourcedEntry
+	for _, scope := range scopes {
+		out, err := exec.Command("git", "config", "-z", scope.flag, "--show-origin", "--get-regexp", pattern).Output()
+		if err != nil {
+			// NOTE: outside of a repository, --local fails as there is no
+			// repository config to read, which is no different from it being
+			// empty.
+			var exitErr *exec.ExitError
+			if scope.flag == "--local" && errors.As(err, &exitErr) {
+				continue
+			}
+			if err := gitConfigError(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// each entry is the origin and "key\nvalue", as for gitConfigRegexp
+		fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+		for i := 0; i+1 < len(fields); i += 2 {
+			entries = append(entries, gitConfigEntry(scope.source, fields[i], fields[i+1]))
+		}
+	}
+	return entries, nil
+}
+
+// gitConfigEntry returns the entry for the origin and "key\nvalue" fields of an
+// entry reported by git config.
+func gitConfigEntry(source Source, origin, keyValue []byte) sourcedEntry {
+	key, value, _ := strings.Cut(string(keyValue), "\n")
+	return sourcedEntry{
+		configEntry: configEntry{key: strings.TrimPrefix(key, "scmpuff."), value: value},
+		source:      source,
+		origin:      strings.TrimPrefix(string(origin), "file:"),
+	}
+}
+
+// gitConfigError returns the error for git config failing with err, which is
+// nil when no keys matched (exit status 1) or git is missing.
+func gitConfigError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil
+	}
+	return fmt.Errorf("failed to read git config: %w", err)
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// setupGitConfig isolates git from the host configuration, using the contents
+// as the global config file, and runs the test outside of any repository with
+// no user config file. It returns the directory the test runs in.
+func setupGitConfig(t *testing.T, contents string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gitconfig")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", path)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	t.Chdir(dir)
+	return dir
+}
+
+// writeUserConfig writes the contents of the user config file for a test
+// prepared with setupGitConfig.
+func writeUserConfig(t *testing.T, dir, contents string) {
+	t.Helper()
+	path := filepath.Join(dir, "xdg", "scmpuff", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNoExpandRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     map[string][]string
+	}{
+		{
+			name:     "none",
+			contents: "[user]\n\tname = Example\n",
+			want:     map[string][]string{},
+		},
+		{
+			name: "multiple subcommands and values",
+			contents: `[scmpuff "noexpand"]
+	log = --author -L
+	log = --foo
+	bar-baz = -x
+`,
+			want: map[string][]string{
+				"log":     {"--author", "-L", "--foo"},
+				"bar-baz": {"-x"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupGitConfig(t, tt.contents)
+			c, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := c.NoExpandRules()
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("NoExpandRules() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConfigConfirmThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     int
+		wantErr  bool
+	}{
+		{name: "default", contents: "", want: DefaultConfirmThreshold},
+		{name: "configured", contents: "[scmpuff]\n\tconfirmThreshold = 10\n", want: 10},
+		{name: "last wins", contents: "[scmpuff]\n\tconfirmThreshold = 10\n\tconfirmThreshold = -1\n", want: -1},
+		{name: "invalid", contents: "[scmpuff]\n\tconfirmThreshold = lots\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupGitConfig(t, tt.contents)
+			c, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.Int("confirmThreshold")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Int() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Int() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	t.Run("git", testConfigPrecedence)
+	t.Run("git without --show-scope", func(t *testing.T) {
+		// a git older than 2.26, failing with a usage error
+		gitPath, err := exec.LookPath("git")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bin := t.TempDir()
+		script := "#!/bin/sh\nfor arg; do [ \"$arg\" = --show-scope ] && exit 129; done\nexec " + gitPath + " \"$@\"\n"
+		if err := os.WriteFile(filepath.Join(bin, "git"), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+		testConfigPrecedence(t)
+	})
+}
+
+func testConfigPrecedence(t *testing.T) {
+	dir := setupGitConfig(t, "[scmpuff]\n\tconfirmThreshold = 1\n[scmpuff \"init\"]\n\tprefix = global\n\twrap = false\n[scmpuff \"noexpand\"]\n\tlog = -a\n")
+	writeUserConfig(t, dir, "confirmThreshold = 2\n[init]\nprefix = \"user\"\n[noexpand]\nlog = [\"-b\", \"-c\"]\n")
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	if out, err := exec.Command("git", "config", "scmpuff.init.prefix", "repo").CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	t.Setenv("SCMPUFF_SHORTCUTS", "file")
+
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key        string
+		wantValue  string
+		wantSource Source
+	}{
+		{key: "init.prefix", wantValue: "repo", wantSource: SourceRepo},
+		{key: "confirmThreshold", wantValue: "2", wantSource: SourceUser},
+		{key: "init.wrap", wantValue: "false", wantSource: SourceGlobal},
+		{key: "init.aliases", wantValue: "true", wantSource: SourceDefault},
+		{key: "init.shortcuts", wantValue: "file", wantSource: SourceEnv},
+		{key: "INIT.PREFIX", wantValue: "repo", wantSource: SourceRepo},
+		{key: "noexpand.log", wantValue: "-a -b -c", wantSource: SourceUser},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := c.Get(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Value != tt.wantValue || got.Source != tt.wantSource {
+				t.Errorf("Get(%q) = %q from %v, want %q from %v", tt.key, got.Value, got.Source, tt.wantValue, tt.wantSource)
+			}
+		})
+	}
+
+	if _, err := c.Get("no.such.setting"); err == nil {
+		t.Errorf("Get() of unknown setting, expected error")
+	}
+}
+
+func TestConfigInvalidValue(t *testing.T) {
+	dir := setupGitConfig(t, "")
+	writeUserConfig(t, dir, "[init]\nshortcuts = \"registry\"\n")
+
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("init.shortcuts"); err == nil {
+		t.Errorf("Get() of invalid value, expected error")
+	}
+}
+
+func TestConfigInvalidEnv(t *testing.T) {
+	setupGitConfig(t, "[scmpuff \"exec\"]\n\tdryRun = true\n")
+	t.Setenv("SCMPUFF_DRY_RUN", "yes")
+
+	// an invalid environment variable is ignored, leaving the lower layers
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Get("exec.dryRun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Value != "true" || got.Source != SourceGlobal {
+		t.Errorf("Get() = %q from %v, want %q from %v", got.Value, got.Source, "true", SourceGlobal)
+	}
+}
+
+func TestSetUser(t *testing.T) {
+	dir := setupGitConfig(t, "")
+	writeUserConfig(t, dir, "# my settings\n[init]\nprefix = \"e\" # the default\n")
+
+	for _, kv := range [][2]string{{"init.prefix", "f"}, {"init.wrap", "false"}, {"confirmThreshold", "9"}} {
+		if _, err := SetUser(kv[0], kv[1]); err != nil {
+			t.Fatalf("SetUser(%q, %q): %v", kv[0], kv[1], err)
+		}
+	}
+	if _, err := SetUser("init.wrap", "sometimes"); err == nil {
+		t.Errorf("SetUser() with invalid value, expected error")
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "xdg", "scmpuff", "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# my settings\nconfirmThreshold = 9\n[init]\nprefix = \"f\"\nwrap = false\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("config file mismatch (-want +got):\n%s", diff)
+	}
+}
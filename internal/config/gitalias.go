@@ -0,0 +1,34 @@
+package config
+
+import (
+	"bytes"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// GitAlias returns the command line that the git alias name stands for, as
+// set by "alias.<name>" in git config, and whether there is such an alias.
+// globals are the git global options the alias is used with (e.g. "-C
+// ../other"), which determine the git config in effect.
+//
+// Aliases that run a shell command ("!...") are not reported, as what follows
+// them is not a git command line, and neither are aliases named like a builtin
+// git command, which git ignores. Any failure to run git is treated as there
+// being no alias, leaving git to report problems itself.
+func GitAlias(globals []string, name string) (string, bool) {
+	out, err := exec.Command("git", slices.Concat(globals, []string{"config", "-z", "--get", "alias." + name})...).Output()
+	if err != nil {
+		return "", false // not set, git config exits with status 1
+	}
+	value := string(bytes.TrimSuffix(out, []byte{0}))
+	if strings.HasPrefix(value, "!") {
+		return "", false
+	}
+
+	out, err = exec.Command("git", slices.Concat(globals, []string{"--list-cmds=builtins"})...).Output()
+	if err != nil || slices.Contains(strings.Fields(string(out)), name) {
+		return "", false
+	}
+	return value, true
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// keyMatcher matches the keys that the expansion of shortcuts in the command
+// line can be bound to (see init.expandKey): a letter with Ctrl (e.g. "C-o")
+// or Alt (e.g. "M-z").
+var keyMatcher = regexp.MustCompile(`^([CM])-([a-z])$`)
+
+// reservedKeys are the keys that are refused for init.expandKey, along with
+// what they are used for, as binding them would take over keys the terminal
+// sends for Enter, Tab and Backspace, terminal control keys, or the prefix of
+// other key bindings in bash and zsh.
+var reservedKeys = map[string]string{
+	"C-c": "interrupt",
+	"C-d": "end of file",
+	"C-h": "backspace",
+	"C-i": "tab",
+	"C-j": "enter",
+	"C-m": "enter",
+	"C-q": "resuming output",
+	"C-s": "stopping output",
+	"C-x": "the prefix of other key bindings",
+	"C-z": "suspend",
+}
+
+var (
+	// ErrInvalidKey is returned by ParseKey for a key that is not a letter
+	// with Ctrl or Alt.
+	ErrInvalidKey = errors.New("must be C-<letter> or M-<letter>")
+
+	// ErrReservedKey is returned by ParseKey for one of the reservedKeys.
+	ErrReservedKey = errors.New("reserved")
+)
+
+// ParseKey parses a key for init.expandKey, given as C-<letter> for Ctrl or
+// M-<letter> for Alt, into its modifier ('C' or 'M') and letter.
+func ParseKey(key string) (modifier, letter byte, err error) {
+	m := keyMatcher.FindStringSubmatch(key)
+	if m == nil {
+		return 0, 0, ErrInvalidKey
+	}
+	if use, ok := reservedKeys[key]; ok {
+		return 0, 0, fmt.Errorf("%s is %w for %s", key, ErrReservedKey, use)
+	}
+	return m[1][0], m[2][0], nil
+}
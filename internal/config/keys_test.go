@@ -0,0 +1,39 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key          string
+		wantModifier byte
+		wantLetter   byte
+		wantErr      error
+	}{
+		{key: "C-o", wantModifier: 'C', wantLetter: 'o'},
+		{key: "M-z", wantModifier: 'M', wantLetter: 'z'},
+		{key: "M-x", wantModifier: 'M', wantLetter: 'x'},
+		{key: "C-x", wantErr: ErrReservedKey},
+		{key: "C-m", wantErr: ErrReservedKey},
+		{key: "C-i", wantErr: ErrReservedKey},
+		{key: "C-c", wantErr: ErrReservedKey},
+		{key: "C-X", wantErr: ErrInvalidKey},
+		{key: "c-o", wantErr: ErrInvalidKey},
+		{key: "C-1", wantErr: ErrInvalidKey},
+		{key: "C-oy", wantErr: ErrInvalidKey},
+		{key: "o", wantErr: ErrInvalidKey},
+		{key: "", wantErr: ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		modifier, letter, err := ParseKey(tt.key)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseKey(%q) error = %v, want %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if modifier != tt.wantModifier || letter != tt.wantLetter {
+			t.Errorf("ParseKey(%q) = %q, %q, want %q, %q", tt.key, modifier, letter, tt.wantModifier, tt.wantLetter)
+		}
+	}
+}
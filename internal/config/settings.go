@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mroth/scmpuff/internal/shortcuts"
+)
+
+// Kind is the type of value a Setting holds.
+type Kind int
+
+// Kind constants
+const (
+	KindString Kind = iota
+	KindBool
+	KindInt
+)
+
+// A Setting describes a configurable value.
+type Setting struct {
+	// Key identifies the setting, e.g. "init.prefix". It is the same in the
+	// config file (as a table and key) and in git config (under "scmpuff.").
+	Key     string
+	Kind    Kind
+	Default string
+	EnvVar  string   // environment variable overriding the setting, if any
+	Choices []string // allowed values, if restricted
+	Usage   string
+}
+
+// Settings are all of the known settings, other than the no-expand rules.
+var Settings = []Setting{
+	{
+		Key:     "confirmThreshold",
+		Kind:    KindInt,
+		Default: strconv.Itoa(DefaultConfirmThreshold),
+		Usage:   "number of file shortcuts a destructive command may target before confirmation (-1 disables)",
+	},
+	{
+		Key:     "exec.dryRun",
+		Kind:    KindBool,
+		Default: "false",
+		EnvVar:  "SCMPUFF_DRY_RUN",
+		Usage:   "print expanded commands instead of executing them",
+	},
+	{
+		Key:     "init.aliases",
+		Kind:    KindBool,
+		Default: "true",
+		Usage:   "include short git aliases in the shell integration",
+	},
+	{
+		Key:     "init.completions",
+		Kind:    KindBool,
+		Default: "true",
+		Usage:   "include tab completion in the shell integration",
+	},
+	{
+		Key:   "init.expandKey",
+		Kind:  KindString,
+		Usage: "key expanding the shortcut at the cursor in the command line (e.g. M-z)",
+	},
+	{
+		Key:     "init.prefix",
+		Kind:    KindString,
+		Default: shortcuts.DefaultPrefix,
+		EnvVar:  shortcuts.PrefixEnvVar,
+		Usage:   "prefix of shortcut variable names",
+	},
+	{
+		Key:     "init.shortcuts",
+		Kind:    KindString,
+		Default: "env",
+		EnvVar:  shortcuts.StoreEnvVar,
+		Choices: []string{"env", "file"},
+		Usage:   "where file shortcuts are kept",
+	},
+	{
+		Key:     "init.wrap",
+		Kind:    KindBool,
+		Default: "true",
+		Usage:   "wrap standard git commands in the shell integration",
+	},
+	{
+		Key:     "status.quotePath",
+		Kind:    KindBool,
+		Default: "false",
+		Usage:   "display paths quoted in C-style, escaping all non-ASCII characters",
+	},
+}
+
+// noExpandKey is the key prefix for user-defined no-expand rules, where the
+// final key component is the git subcommand, e.g. in git config:
+//
+//	[scmpuff "noexpand"]
+//		log = --author -L
+//		foo = -x
+const noExpandKey = "noexpand."
+
+// lookupSetting returns the Setting for key, which is matched without regard
+// to case as in git config. No-expand rules (e.g. "noexpand.log"), aliases
+// (e.g. "alias.gs") and wrapper rules (e.g. "wrapper.add") are described by a
+// string Setting of their own.
+func lookupSetting(key string) (Setting, bool) {
+	if subcommand, ok := cutPrefixFold(key, noExpandKey); ok && subcommand != "" {
+		return Setting{
+			Key:   noExpandKey + subcommand,
+			Kind:  KindString,
+			Usage: "options of git " + subcommand + " whose value is never expanded",
+		}, true
+	}
+	if name, ok := cutPrefixFold(key, aliasKey); ok && ValidAliasName(name) {
+		s := Setting{
+			Key:   aliasKey + name,
+			Kind:  KindString,
+			Usage: "command run by the " + name + " alias, or empty to disable it",
+		}
+		if i := slices.IndexFunc(DefaultAliases, func(a Alias) bool { return strings.EqualFold(a.Name, name) }); i != -1 {
+			s.Key, s.Default = aliasKey+DefaultAliases[i].Name, DefaultAliases[i].Command
+		}
+		return s, true
+	}
+	if subcommand, ok := cutPrefixFold(key, wrapperKey); ok && subcommandRegexp.MatchString(subcommand) {
+		subcommand = strings.ToLower(subcommand)
+		s := Setting{
+			Key:   wrapperKey + subcommand,
+			Kind:  KindString,
+			Usage: "how the git wrapper runs git " + subcommand,
+		}
+		if i := slices.IndexFunc(DefaultWrapRules, func(r WrapRule) bool { return r.Subcommand == subcommand }); i != -1 {
+			s.Default = DefaultWrapRules[i].String()
+		} else {
+			s.Default = WrapOff.String()
+		}
+		return s, true
+	}
+	i := slices.IndexFunc(Settings, func(s Setting) bool {
+		return strings.EqualFold(s.Key, key)
+	})
+	if i == -1 {
+		return Setting{}, false
+	}
+	return Settings[i], true
+}
+
+// validate returns an error if value is not valid for s.
+func (s Setting) validate(value string) error {
+	var err error
+	switch s.Kind {
+	case KindBool:
+		_, err = strconv.ParseBool(value)
+	case KindInt:
+		_, err = strconv.Atoi(value)
+	case KindString:
+	default:
+		panic(fmt.Sprintf("unknown setting kind: %d", s.Kind))
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value for %s %q: %w", s.Key, value, err)
+	}
+
+	if len(s.Choices) > 0 && !slices.Contains(s.Choices, value) {
+		return fmt.Errorf("invalid value for %s %q: must be one of %s", s.Key, value, strings.Join(s.Choices, ", "))
+	}
+	if subcommand, ok := cutPrefixFold(s.Key, wrapperKey); ok {
+		if _, err := ParseWrapRule(subcommand, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", s.Key, err)
+		}
+	}
+	if s.Key == "init.expandKey" && value != "" {
+		if _, _, err := ParseKey(value); err != nil {
+			return fmt.Errorf("invalid value for %s %q: %w", s.Key, value, err)
+		}
+	}
+	if s.Key == "init.prefix" && !shortcuts.ValidPrefix(value) {
+		return fmt.Errorf("invalid value for %s %q: must be a valid shell variable name", s.Key, value)
+	}
+	return nil
+}
+
+// cutPrefixFold is strings.CutPrefix, without regard to case.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// The user config file is TOML, where settings are keyed by their table:
+//
+//	# comments
+//	confirmThreshold = 10
+//
+//	[init]
+//	prefix = "f"
+//	aliases = false
+//
+//	[noexpand]
+//	log = ["--author", "-L"]
+//
+// Arrays produce one entry per element, in the same way as a git config key
+// set multiple times. Arrays of tables have no meaning for scmpuff settings,
+// and are refused.
+
+// parseTOML parses the contents of a config file into its entries, with keys
+// fully qualified by their table (e.g. "init.prefix"), in the order they are
+// defined.
+func parseTOML(data string) ([]configEntry, error) {
+	var doc map[string]any
+	md, err := toml.Decode(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []configEntry
+	for _, key := range md.Keys() {
+		name := strings.Join(key, ".")
+		switch md.Type(key...) {
+		case "Hash":
+			continue // its keys follow
+		case "ArrayHash":
+			return nil, fmt.Errorf("%s: arrays of tables are not supported", name)
+		}
+
+		var values []any
+		switch v := lookupTOML(doc, key).(type) {
+		case []any:
+			values = v
+		default:
+			values = []any{v}
+		}
+		for _, v := range values {
+			switch v.(type) {
+			case []any, map[string]any:
+				return nil, fmt.Errorf("%s: arrays may only contain plain values", name)
+			}
+			entries = append(entries, configEntry{key: name, value: formatTOML(v)})
+		}
+	}
+	return entries, nil
+}
+
+// lookupTOML returns the value of key in the decoded document doc.
+func lookupTOML(doc map[string]any, key toml.Key) any {
+	var v any = doc
+	for _, part := range key {
+		table, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = table[part]
+	}
+	return v
+}
+
+// formatTOML returns a decoded plain value in the string form of a setting.
+func formatTOML(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// setTOML returns data with key set to value, replacing any existing
+// assignment of key, or otherwise adding it to the appropriate table. Values
+// of kind KindString are written quoted.
+//
+// The file is edited line by line, so that the formatting and comments of the
+// rest of it are kept, and any statement spanning several lines (such as a
+// multi-line array) is replaced as a whole.
+func setTOML(data, key, value string, kind Kind) (string, error) {
+	formatted := value
+	if kind == KindString {
+		formatted = quoteTOML(value)
+	}
+
+	table, leaf := "", key
+	if i := strings.LastIndexByte(key, '.'); i != -1 {
+		table, leaf = key[:i], key[i+1:]
+	}
+
+	lines := strings.Split(data, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	current := ""
+	insertAt := -1    // line index after the last statement of the target table
+	firstHeader := -1 // line index of the first table header
+	for i := 0; i < len(lines); i++ {
+		name, header, end, err := statementAt(lines, i)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case name == "":
+			// a blank or comment line
+		case header:
+			if firstHeader == -1 {
+				firstHeader = i
+			}
+			current = name
+			if strings.EqualFold(current, table) {
+				insertAt = end + 1
+			}
+		case strings.EqualFold(qualify(current, name), key):
+			// keep the key as originally written, dropping the old value
+			before, _, _ := cutUnquoted(lines[i], '=')
+			assignment := strings.TrimRight(before, " \t") + " = " + formatted
+			lines = append(lines[:i], append([]string{assignment}, lines[end+1:]...)...)
+			return checkTOML(strings.Join(lines, "\n")+"\n", key)
+		case strings.EqualFold(current, table):
+			insertAt = end + 1
+		}
+		i = end
+	}
+
+	assignment := leaf + " = " + formatted
+	switch {
+	case table == "" && firstHeader == -1:
+		lines = append(lines, assignment)
+	case table == "":
+		lines = insertLine(lines, firstHeader, assignment)
+	case insertAt != -1:
+		lines = insertLine(lines, insertAt, assignment)
+	default:
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+table+"]", assignment)
+	}
+	return checkTOML(strings.Join(lines, "\n")+"\n", key)
+}
+
+// statementAt returns the TOML statement starting at lines[i]: the name of the
+// table for a header, or the (possibly dotted) key of a key/value pair, or ""
+// for a blank or comment line, along with the index of its last line.
+func statementAt(lines []string, i int) (name string, header bool, end int, err error) {
+	// a statement spans the fewest lines that are valid TOML on their own
+	for end = i; end < len(lines); end++ {
+		md, err := toml.Decode(strings.Join(lines[i:end+1], "\n"), new(map[string]any))
+		if err != nil {
+			continue
+		}
+		keys := md.Keys()
+		if len(keys) == 0 {
+			return "", false, end, nil
+		}
+		header = strings.HasPrefix(strings.TrimSpace(lines[i]), "[")
+		return strings.Join(keys[0], "."), header, end, nil
+	}
+	return "", false, 0, fmt.Errorf("line %d: invalid TOML", i+1)
+}
+
+// checkTOML returns data if it is still a valid config file, which it may not
+// be if key was defined in a form that cannot be edited line by line (e.g. in
+// an inline table).
+func checkTOML(data, key string) (string, error) {
+	if _, err := parseTOML(data); err != nil {
+		return "", fmt.Errorf("cannot set %s without breaking the config file, edit it by hand instead: %w", key, err)
+	}
+	return data, nil
+}
+
+// quoteTOML returns s as a TOML basic string.
+func quoteTOML(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func insertLine(lines []string, at int, line string) []string {
+	return append(lines[:at], append([]string{line}, lines[at:]...)...)
+}
+
+func qualify(table, key string) string {
+	if table == "" {
+		return key
+	}
+	return table + "." + key
+}
+
+// cutUnquoted is strings.Cut on the first sep that is not within quotes.
+func cutUnquoted(s string, sep byte) (string, string, bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == 0 && c == sep:
+			return s[:i], s[i+1:], true
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+	return s, "", false
+}
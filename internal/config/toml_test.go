@@ -0,0 +1,188 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []configEntry
+		wantErr bool
+	}{
+		{name: "empty", data: "", want: nil},
+		{
+			name: "tables and comments",
+			data: `# top level
+confirmThreshold = 10 # trailing
+
+[init]
+prefix = "f"
+aliases = false
+
+[ status ]
+quotePath = true
+`,
+			want: []configEntry{
+				{"confirmThreshold", "10"},
+				{"init.prefix", "f"},
+				{"init.aliases", "false"},
+				{"status.quotePath", "true"},
+			},
+		},
+		{
+			name: "dotted and quoted keys",
+			data: "init.prefix = 'f'\n\"init\".\"wrap\" = true\n",
+			want: []configEntry{{"init.prefix", "f"}, {"init.wrap", "true"}},
+		},
+		{
+			name: "strings",
+			data: `a = "say \"hi\" # not a comment"` + "\n" + `b = 'C:\path'` + "\n" + `c = "\u00e9\t"`,
+			want: []configEntry{{"a", `say "hi" # not a comment`}, {"b", `C:\path`}, {"c", "é\t"}},
+		},
+		{
+			name: "arrays",
+			data: `[noexpand]` + "\n" + `log = ["--author", '-L', ]` + "\n" + `foo = []`,
+			want: []configEntry{{"noexpand.log", "--author"}, {"noexpand.log", "-L"}},
+		},
+		{name: "integers", data: "a = -1\nb = 1_000\nc = 0x10", want: []configEntry{{"a", "-1"}, {"b", "1000"}, {"c", "16"}}},
+		{
+			name: "multi-line values",
+			data: "[noexpand]\nlog = [\n  \"--author\", # who\n  \"-L\",\n]\n[alias]\nst = \"\"\"\nstatus \\\n  --short\"\"\"\n",
+			want: []configEntry{{"noexpand.log", "--author"}, {"noexpand.log", "-L"}, {"alias.st", "status --short"}},
+		},
+		{
+			name: "inline tables",
+			data: `init = { prefix = "f", aliases = false }`,
+			want: []configEntry{{"init.prefix", "f"}, {"init.aliases", "false"}},
+		},
+		{name: "missing value", data: "a =", wantErr: true},
+		{name: "missing equals", data: "a", wantErr: true},
+		{name: "bare word value", data: "a = yes", wantErr: true},
+		{name: "unterminated string", data: `a = "foo`, wantErr: true},
+		{name: "invalid escape", data: `a = "\x"`, wantErr: true},
+		{name: "trailing garbage", data: `a = "foo" bar`, wantErr: true},
+		{name: "unterminated array", data: `a = ["foo"`, wantErr: true},
+		{name: "invalid table", data: "[init", wantErr: true},
+		{name: "array of tables", data: "[[init]]", wantErr: true},
+		{name: "nested array", data: "a = [[1]]", wantErr: true},
+		{name: "duplicate key", data: "a = 1\na = 2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTOML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(configEntry{})); diff != "" {
+				t.Errorf("parseTOML() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetTOML(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		key   string
+		value string
+		kind  Kind
+		want  string
+	}{
+		{
+			name: "empty file",
+			key:  "init.prefix", value: "f", kind: KindString,
+			want: "[init]\nprefix = \"f\"\n",
+		},
+		{
+			name: "top level key before tables",
+			data: "[init]\nprefix = \"f\"\n",
+			key:  "confirmThreshold", value: "3", kind: KindInt,
+			want: "confirmThreshold = 3\n[init]\nprefix = \"f\"\n",
+		},
+		{
+			name: "replace in table",
+			data: "[init]\n  prefix = \"f\" # mine\nwrap = true\n",
+			key:  "init.prefix", value: "g", kind: KindString,
+			want: "[init]\n  prefix = \"g\"\nwrap = true\n",
+		},
+		{
+			name: "replace dotted key",
+			data: "init.wrap = true\n",
+			key:  "init.wrap", value: "false", kind: KindBool,
+			want: "init.wrap = false\n",
+		},
+		{
+			name: "append to existing table",
+			data: "[init]\nprefix = \"f\"\n\n[status]\nquotePath = true\n",
+			key:  "init.wrap", value: "false", kind: KindBool,
+			want: "[init]\nprefix = \"f\"\nwrap = false\n\n[status]\nquotePath = true\n",
+		},
+		{
+			name: "new table",
+			data: "[init]\nprefix = \"f\"\n",
+			key:  "status.quotePath", value: "true", kind: KindBool,
+			want: "[init]\nprefix = \"f\"\n\n[status]\nquotePath = true\n",
+		},
+		{
+			name: "case insensitive match",
+			data: "[init]\nPrefix = \"f\"\n",
+			key:  "init.prefix", value: "g", kind: KindString,
+			want: "[init]\nPrefix = \"g\"\n",
+		},
+		{
+			name: "replace multi-line array",
+			data: "[noexpand]\nlog = [\n  \"-L\",\n]\ndiff = \"-x\"\n",
+			key:  "noexpand.log", value: "--author", kind: KindString,
+			want: "[noexpand]\nlog = \"--author\"\ndiff = \"-x\"\n",
+		},
+		{
+			name: "append after multi-line string",
+			data: "[alias]\nst = \"\"\"\n[status]\nx = 1\"\"\"\n",
+			key:  "alias.co", value: "checkout", kind: KindString,
+			want: "[alias]\nst = \"\"\"\n[status]\nx = 1\"\"\"\nco = \"checkout\"\n",
+		},
+		{
+			name: "string escaping",
+			data: "",
+			key:  "noexpand.log", value: `a"b\c`, kind: KindString,
+			want: "[noexpand]\nlog = \"a\\\"b\\\\c\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setTOML(tt.data, tt.key, tt.value, tt.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("setTOML() mismatch (-want +got):\n%s", diff)
+			}
+			// the result must always read back with the new value
+			entries, err := parseTOML(got)
+			if err != nil {
+				t.Fatalf("parseTOML() of result: %v", err)
+			}
+			if !slices.ContainsFunc(entries, func(e configEntry) bool {
+				return strings.EqualFold(e.key, tt.key) && e.value == tt.value
+			}) {
+				t.Errorf("parseTOML() of result does not contain %s = %q", tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestSetTOML_InlineTable(t *testing.T) {
+	// a key of an inline table cannot be replaced line by line, and adding a
+	// table of the same name would break the file
+	data := "init = { prefix = \"f\" }\n"
+	if got, err := setTOML(data, "init.prefix", "g", KindString); err == nil {
+		t.Errorf("setTOML() = %q, want error", got)
+	}
+}
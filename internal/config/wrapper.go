@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// WrapMode is how scmpuff git runs a git subcommand.
+type WrapMode int
+
+// WrapMode constants
+const (
+	WrapOff      WrapMode = iota // run git directly, without expansion
+	WrapAbsolute                 // expand file shortcuts to absolute paths
+	WrapRelative                 // expand file shortcuts to paths relative to the working directory
+)
+
+var wrapModeNames = map[WrapMode]string{
+	WrapOff:      "off",
+	WrapAbsolute: "absolute",
+	WrapRelative: "relative",
+}
+
+func (m WrapMode) String() string {
+	if name, ok := wrapModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("WrapMode(%d)", int(m))
+}
+
+// RefreshMode is how the status is refreshed after scmpuff git runs a git
+// subcommand, so that shortcuts keep up with the changes it made.
+type RefreshMode int
+
+// RefreshMode constants
+const (
+	RefreshNone    RefreshMode = iota // leave the shortcuts as they are
+	RefreshStatus                     // display the full status
+	RefreshSummary                    // display a one line summary of the status
+	RefreshQuiet                      // update the shortcuts without displaying anything
+)
+
+var refreshModeNames = map[RefreshMode]string{
+	RefreshStatus:  "refresh",
+	RefreshSummary: "refresh=summary",
+	RefreshQuiet:   "refresh=quiet",
+}
+
+func (m RefreshMode) String() string {
+	if m == RefreshNone {
+		return "none"
+	}
+	if name, ok := refreshModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("RefreshMode(%d)", int(m))
+}
+
+// A WrapRule is an entry in the dispatch table of scmpuff git, determining how
+// a git subcommand is run.
+type WrapRule struct {
+	Subcommand string
+	Mode       WrapMode
+	Refresh    RefreshMode
+}
+
+// String returns the rule in the form it is configured in, e.g. "relative" or
+// "absolute refresh=quiet".
+func (r WrapRule) String() string {
+	if r.Refresh != RefreshNone {
+		return r.Mode.String() + " " + r.Refresh.String()
+	}
+	return r.Mode.String()
+}
+
+// DefaultWrapRules are the wrapper dispatch table used unless configured
+// otherwise.
+//
+// NOTE: When adding a subcommand here, also add a model of its command line to
+// the arguments package, otherwise numeric tokens will only be expanded after a
+// "--" separator.
+var DefaultWrapRules = []WrapRule{
+	{Subcommand: "add", Mode: WrapAbsolute, Refresh: RefreshStatus},
+	{Subcommand: "blame", Mode: WrapAbsolute},
+	{Subcommand: "cat-file", Mode: WrapAbsolute},
+	{Subcommand: "checkout", Mode: WrapRelative, Refresh: RefreshSummary},
+	{Subcommand: "clean", Mode: WrapRelative},
+	{Subcommand: "commit", Mode: WrapAbsolute, Refresh: RefreshSummary},
+	{Subcommand: "diff", Mode: WrapRelative},
+	{Subcommand: "difftool", Mode: WrapRelative},
+	{Subcommand: "grep", Mode: WrapRelative},
+	{Subcommand: "log", Mode: WrapAbsolute},
+	{Subcommand: "merge", Mode: WrapAbsolute},
+	{Subcommand: "mergetool", Mode: WrapRelative},
+	{Subcommand: "mv", Mode: WrapRelative},
+	{Subcommand: "rebase", Mode: WrapAbsolute},
+	{Subcommand: "reset", Mode: WrapRelative, Refresh: RefreshSummary},
+	{Subcommand: "restore", Mode: WrapRelative, Refresh: RefreshSummary},
+	{Subcommand: "rm", Mode: WrapRelative, Refresh: RefreshSummary},
+	{Subcommand: "show", Mode: WrapAbsolute},
+	{Subcommand: "stash", Mode: WrapRelative, Refresh: RefreshSummary},
+	{Subcommand: "switch", Mode: WrapAbsolute},
+}
+
+// PassthroughSubcommands are frequently used git builtins that are not in
+// DefaultWrapRules and rarely, if ever, take file arguments. "scmpuff git" runs
+// them directly, without loading the configuration or looking up git aliases
+// (which cannot be named like a builtin), as almost every git command typed in
+// the shell goes through it. As such, they cannot have wrapper rules.
+var PassthroughSubcommands = []string{
+	"branch", "clone", "config", "describe", "fetch", "help", "init", "push",
+	"remote", "status", "submodule", "tag", "version", "worktree",
+}
+
+// wrapperKey is the key prefix for the wrapper dispatch table, where the final
+// key component is the git subcommand, e.g. in the config file:
+//
+//	[wrapper]
+//	grep = "off"
+//	stash = "relative refresh"
+//	commit = "absolute refresh=quiet"
+const wrapperKey = "wrapper."
+
+// subcommandRegexp matches plausible git subcommand names, which also keeps
+// the keys of wrapper rules unambiguous in both config sources.
+var subcommandRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ParseWrapRule parses the configured value of the wrapper rule for a git
+// subcommand, which is a mode ("off", "absolute" or "relative") optionally
+// followed by how to refresh the status afterward ("refresh",
+// "refresh=summary" or "refresh=quiet").
+func ParseWrapRule(subcommand, value string) (WrapRule, error) {
+	rule := WrapRule{Subcommand: subcommand}
+	if !subcommandRegexp.MatchString(subcommand) {
+		return rule, fmt.Errorf("invalid git subcommand %q", subcommand)
+	}
+	if slices.Contains(PassthroughSubcommands, strings.ToLower(subcommand)) {
+		return rule, fmt.Errorf("git %s is always run directly, so cannot have a wrapper rule", subcommand)
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return rule, fmt.Errorf(`invalid wrapper rule %q: must be "off", "absolute" or "relative", optionally followed by "refresh"`, value)
+	}
+	if len(fields) == 2 {
+		refresh, ok := findName(refreshModeNames, fields[1])
+		if !ok {
+			return rule, fmt.Errorf(`invalid wrapper refresh %q: must be "refresh", "refresh=summary" or "refresh=quiet"`, fields[1])
+		}
+		rule.Refresh = refresh
+	}
+	mode, ok := findName(wrapModeNames, fields[0])
+	if !ok {
+		return rule, fmt.Errorf(`invalid wrapper mode %q: must be "off", "absolute" or "relative"`, fields[0])
+	}
+	rule.Mode = mode
+	return rule, nil
+}
+
+// findName returns the key of names whose name is name, and whether there is
+// one.
+func findName[K comparable](names map[K]string, name string) (K, bool) {
+	for k, n := range names {
+		if n == name {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// WrapRules returns the wrapper dispatch table in effect: the
+// DefaultWrapRules, replaced or extended by any configured
+// "wrapper.<subcommand>" settings, in order of subcommand.
+func (c *Config) WrapRules() ([]WrapRule, error) {
+	rules := slices.Clone(DefaultWrapRules)
+	for _, e := range c.entries {
+		subcommand, ok := cutPrefixFold(e.key, wrapperKey)
+		if !ok {
+			continue
+		}
+		subcommand = strings.ToLower(subcommand)
+		rule, err := ParseWrapRule(subcommand, e.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", Value{Source: e.source, Origin: e.origin}.Location(), err)
+		}
+		if i := slices.IndexFunc(rules, func(r WrapRule) bool { return r.Subcommand == subcommand }); i != -1 {
+			rules[i] = rule
+		} else {
+			rules = append(rules, rule)
+		}
+	}
+
+	slices.SortFunc(rules, func(a, b WrapRule) int { return strings.Compare(a.Subcommand, b.Subcommand) })
+	return rules, nil
+}
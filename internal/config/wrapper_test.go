@@ -0,0 +1,45 @@
+package config
+
+import "testing"
+
+func TestParseWrapRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    WrapRule
+		wantErr bool
+	}{
+		{value: "off", want: WrapRule{Subcommand: "x", Mode: WrapOff}},
+		{value: "relative", want: WrapRule{Subcommand: "x", Mode: WrapRelative}},
+		{value: "absolute refresh", want: WrapRule{Subcommand: "x", Mode: WrapAbsolute, Refresh: RefreshStatus}},
+		{value: " relative  refresh=summary ", want: WrapRule{Subcommand: "x", Mode: WrapRelative, Refresh: RefreshSummary}},
+		{value: "off refresh=quiet", want: WrapRule{Subcommand: "x", Mode: WrapOff, Refresh: RefreshQuiet}},
+		{value: "", wantErr: true},
+		{value: "sideways", wantErr: true},
+		{value: "relative refresh=loud", wantErr: true},
+		{value: "relative quiet", wantErr: true},
+		{value: "relative refresh extra", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWrapRule("x", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWrapRule(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseWrapRule(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+		if again, err := ParseWrapRule(got.Subcommand, got.String()); err != nil || again != got {
+			t.Errorf("ParseWrapRule(%q) = %+v, %v, want %+v", got.String(), again, err, got)
+		}
+	}
+
+	if _, err := ParseWrapRule("not a subcommand", "off"); err == nil {
+		t.Errorf("expected error for invalid subcommand")
+	}
+	if _, err := ParseWrapRule("push", "absolute"); err == nil {
+		t.Errorf("expected error for a passthrough subcommand")
+	}
+}
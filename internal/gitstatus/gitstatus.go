@@ -64,7 +64,10 @@ func (si StatusItem) AbsPath(root string) string {
 // For renamed/copied files, it shows "from -> to" format.
 // Paths are shown relative to cwd when possible, otherwise absolute.
 // Paths are always in POSIX style for git consistency.
-func (si StatusItem) DisplayPath(root, cwd string) string {
+//
+// Each path is escaped according to quoting (see QuotePath), so that control
+// characters in filenames can never reach the terminal unescaped.
+func (si StatusItem) DisplayPath(root, cwd string, quoting PathQuoting) string {
 	// Normalize all paths to use the same separator style, so that we can
 	// reliably calculate relative paths. root and cwd should be in POSIX style
 	// for consistency with git repo path in the StatusItem.Path. root probably
@@ -81,9 +84,9 @@ func (si StatusItem) DisplayPath(root, cwd string) string {
 	relPath := func(repoPath string) string {
 		absPath := filepath.ToSlash(filepath.Join(root, repoPath))
 		if relPath, err := filepath.Rel(cwd, absPath); err == nil {
-			return filepath.ToSlash(relPath)
+			return QuotePath(filepath.ToSlash(relPath), quoting)
 		}
-		return absPath
+		return QuotePath(absPath, quoting)
 	}
 
 	// Handle renamed/copied files with "from -> to" format
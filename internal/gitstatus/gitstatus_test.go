@@ -71,6 +71,14 @@ func TestStatusItem(t *testing.T) {
 			wantAbsPath:  "/tmp/foo/b.txt",
 			wantDispPath: "../a.txt -> ../b.txt",
 		},
+		{
+			name:         "control characters are escaped",
+			item:         StatusItem{Path: "new\x1b[2Jname.txt", OrigPath: "old\nname.txt"},
+			root:         "/tmp/foo",
+			wd:           "/tmp/foo",
+			wantAbsPath:  "/tmp/foo/new\x1b[2Jname.txt",
+			wantDispPath: `"old\nname.txt" -> "new\033[2Jname.txt"`,
+		},
 		{
 			name:            "windows style paths",
 			item:            StatusItem{Path: "bar/a.txt"}, // StatusItem Paths are always POSIX style, like git
@@ -93,7 +101,7 @@ func TestStatusItem(t *testing.T) {
 				t.Errorf("StatusItem.AbsPath() = %v, want %v", gotAbsPath, tc.wantAbsPath)
 			}
 
-			gotRelPath := tc.item.DisplayPath(tc.root, tc.wd)
+			gotRelPath := tc.item.DisplayPath(tc.root, tc.wd, QuoteUnprintable)
 			if gotRelPath != tc.wantDispPath {
 				t.Errorf("StatusItem.DisplayPath() = %v, want %v", gotRelPath, tc.wantDispPath)
 			}
@@ -0,0 +1,89 @@
+package gitstatus
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PathQuoting determines how unusual characters in paths are escaped for
+// display, mirroring the behavior of git's core.quotePath setting.
+type PathQuoting int
+
+const (
+	// QuoteUnprintable quotes a path only if it contains control characters,
+	// other non-printable characters, invalid UTF-8, or the double quote and
+	// backslash characters used for quoting. Printable non-ASCII characters are
+	// left as-is. This is equivalent to git with core.quotePath=false, but also
+	// escapes non-printable Unicode characters (e.g. C1 controls and
+	// bidirectional overrides) that could otherwise manipulate the terminal.
+	QuoteUnprintable PathQuoting = iota
+	// QuoteCStyle additionally quotes any path containing bytes outside of the
+	// ASCII range, escaping them in octal. This is equivalent to git with
+	// core.quotePath=true (the git default).
+	QuoteCStyle
+)
+
+// cEscapes are the characters with a short C-style escape sequence, as used by
+// git's quote_c_style.
+var cEscapes = map[rune]string{
+	'\a': `\a`,
+	'\b': `\b`,
+	'\t': `\t`,
+	'\n': `\n`,
+	'\v': `\v`,
+	'\f': `\f`,
+	'\r': `\r`,
+	'"':  `\"`,
+	'\\': `\\`,
+}
+
+// QuotePath returns path in a form that is safe to print to a terminal.
+//
+// Paths that need no escaping under the given quoting style are returned
+// unchanged. Otherwise, the path is wrapped in double quotes with special
+// characters escaped in C-style, as git does (e.g. "tab\there" or "\303\251").
+func QuotePath(path string, quoting PathQuoting) string {
+	var b strings.Builder
+	needsQuotes := false
+	for i := 0; i < len(path); {
+		r, size := utf8.DecodeRuneInString(path[i:])
+		raw := path[i : i+size]
+		i += size
+
+		if esc, ok := cEscapes[r]; ok {
+			b.WriteString(esc)
+			needsQuotes = true
+			continue
+		}
+		if needsOctal(r, size, quoting) {
+			for _, c := range []byte(raw) {
+				fmt.Fprintf(&b, `\%03o`, c)
+			}
+			needsQuotes = true
+			continue
+		}
+		b.WriteString(raw)
+	}
+
+	if !needsQuotes {
+		return path
+	}
+	return `"` + b.String() + `"`
+}
+
+// needsOctal reports whether the rune r (decoded from size bytes) must be
+// escaped as octal bytes under the given quoting style.
+func needsOctal(r rune, size int, quoting PathQuoting) bool {
+	switch {
+	case r == utf8.RuneError && size == 1: // invalid UTF-8
+		return true
+	case r < utf8.RuneSelf:
+		return r < ' ' || r == 0x7f
+	case quoting == QuoteCStyle:
+		return true
+	default:
+		return !unicode.IsPrint(r)
+	}
+}
@@ -0,0 +1,84 @@
+package gitstatus
+
+import "testing"
+
+func TestQuotePath(t *testing.T) {
+	testcases := []struct {
+		name       string
+		path       string
+		wantPlain  string // QuoteUnprintable
+		wantCStyle string // QuoteCStyle
+	}{
+		{
+			name:       "plain ascii",
+			path:       "dir/a file.txt",
+			wantPlain:  "dir/a file.txt",
+			wantCStyle: "dir/a file.txt",
+		},
+		{
+			name:       "printable unicode",
+			path:       "修改后的文件.php",
+			wantPlain:  "修改后的文件.php",
+			wantCStyle: `"\344\277\256\346\224\271\345\220\216\347\232\204\346\226\207\344\273\266.php"`,
+		},
+		{
+			name:       "emoji",
+			path:       "👻.go",
+			wantPlain:  "👻.go",
+			wantCStyle: `"\360\237\221\273.go"`,
+		},
+		{
+			name:       "short escapes",
+			path:       "tab\there\nnew\rline",
+			wantPlain:  `"tab\there\nnew\rline"`,
+			wantCStyle: `"tab\there\nnew\rline"`,
+		},
+		{
+			name:       "quote and backslash",
+			path:       `say "hi"\bye`,
+			wantPlain:  `"say \"hi\"\\bye"`,
+			wantCStyle: `"say \"hi\"\\bye"`,
+		},
+		{
+			name:       "terminal escape sequence",
+			path:       "\x1b[31mred\x1b[0m.txt",
+			wantPlain:  `"\033[31mred\033[0m.txt"`,
+			wantCStyle: `"\033[31mred\033[0m.txt"`,
+		},
+		{
+			name:       "delete",
+			path:       "a\x7fb",
+			wantPlain:  `"a\177b"`,
+			wantCStyle: `"a\177b"`,
+		},
+		{
+			name:       "C1 control",
+			path:       "a\u009bb",
+			wantPlain:  `"a\302\233b"`,
+			wantCStyle: `"a\302\233b"`,
+		},
+		{
+			name:       "bidi override",
+			path:       "a\u202eb",
+			wantPlain:  `"a\342\200\256b"`,
+			wantCStyle: `"a\342\200\256b"`,
+		},
+		{
+			name:       "invalid utf-8",
+			path:       "a\xffb",
+			wantPlain:  `"a\377b"`,
+			wantCStyle: `"a\377b"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := QuotePath(tc.path, QuoteUnprintable); got != tc.wantPlain {
+				t.Errorf("QuotePath(%q, QuoteUnprintable) = %s, want %s", tc.path, got, tc.wantPlain)
+			}
+			if got := QuotePath(tc.path, QuoteCStyle); got != tc.wantCStyle {
+				t.Errorf("QuotePath(%q, QuoteCStyle) = %s, want %s", tc.path, got, tc.wantCStyle)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package shortcuts
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FilelistHeader is the first record written by WriteFilelist, identifying the
+// version of the filelist protocol so that readers can verify it.
+const FilelistHeader = "scmpuff-filelist-v3"
+
+// A Set is the numbered list of files assigned shortcuts in a repository.
+type Set struct {
+	Root  string   // absolute path of the repository root
+	Paths []string // absolute paths, so that shortcut N is at index N-1
+}
+
+// Fingerprint returns the Fingerprint of s.
+func (s Set) Fingerprint() Fingerprint {
+	return NewFingerprint(s.Root, s.Paths)
+}
+
+// Lookup returns the path for the shortcut variable name (e.g. "e1"), and
+// whether it is assigned in s.
+func (s Set) Lookup(name string) (string, bool) {
+	n, ok := ParseVarName(name)
+	if !ok || n < 1 || n > len(s.Paths) {
+		return "", false
+	}
+	return s.Paths[n-1], true
+}
+
+// WriteFilelist writes s to w using version 3 of the filelist protocol, as read
+// by the scmpuff_status() shell functions and ReadFilelist.
+//
+// Each record is terminated by a NUL byte, which can never appear in a path, so
+// this is safe for paths containing tabs, newlines, or any other character. The
+// first record is FilelistHeader, the second is the Fingerprint of the set,
+// followed by one record per file in shortcut order.
+func WriteFilelist(w io.Writer, s Set) error {
+	b := bufio.NewWriter(w)
+	b.WriteString(FilelistHeader)
+	b.WriteByte(0)
+	b.WriteString(s.Fingerprint().String())
+	b.WriteByte(0)
+	for _, path := range s.Paths {
+		b.WriteString(path)
+		b.WriteByte(0)
+	}
+	return b.Flush()
+}
+
+// ReadFilelist reads a Set written by WriteFilelist from r.
+func ReadFilelist(r io.Reader) (Set, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Set{}, err
+	}
+	records, ok := bytes.CutSuffix(data, []byte{0})
+	if !ok {
+		return Set{}, fmt.Errorf("malformed filelist: missing record terminator")
+	}
+	fields := strings.Split(string(records), "\x00")
+	if len(fields) < 2 || fields[0] != FilelistHeader {
+		return Set{}, fmt.Errorf("unrecognized filelist protocol (want %s)", FilelistHeader)
+	}
+	fingerprint, err := ParseFingerprint(fields[1])
+	if err != nil {
+		return Set{}, err
+	}
+
+	s := Set{Root: fingerprint.Root, Paths: fields[2:]}
+	if s.Fingerprint() != fingerprint {
+		return Set{}, fmt.Errorf("malformed filelist: fingerprint does not match contents")
+	}
+	return s, nil
+}
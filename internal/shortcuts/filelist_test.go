@@ -0,0 +1,70 @@
+package shortcuts
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilelistRoundTrip(t *testing.T) {
+	for _, s := range []Set{
+		{Root: "/repo", Paths: []string{"/repo/a.txt", "/repo/tab\there.txt", "/repo/new\nline.txt"}},
+		{Root: "/repo", Paths: []string{}},
+	} {
+		var buf bytes.Buffer
+		if err := WriteFilelist(&buf, s); err != nil {
+			t.Fatalf("WriteFilelist() error: %v", err)
+		}
+		got, err := ReadFilelist(&buf)
+		if err != nil {
+			t.Fatalf("ReadFilelist() error: %v", err)
+		}
+		if diff := cmp.Diff(s, got); diff != "" {
+			t.Errorf("ReadFilelist() mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestReadFilelistErrors(t *testing.T) {
+	valid := FilelistHeader + "\x00" + NewFingerprint("/repo", []string{"/repo/a"}).String() + "\x00/repo/a\x00"
+	testcases := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ""},
+		{name: "unterminated", data: strings.TrimSuffix(valid, "\x00")},
+		{name: "old protocol", data: "scmpuff-filelist-v2\x00/repo/a\x00"},
+		{name: "tampered", data: valid + "/repo/b\x00"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReadFilelist(strings.NewReader(tc.data)); err == nil {
+				t.Errorf("ReadFilelist(%q) expected error", tc.data)
+			}
+		})
+	}
+}
+
+func TestSetLookup(t *testing.T) {
+	s := Set{Root: "/repo", Paths: []string{"/repo/a.txt", "/repo/b.txt"}}
+	testcases := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{name: "e1", want: "/repo/a.txt", wantOk: true},
+		{name: "e2", want: "/repo/b.txt", wantOk: true},
+		{name: "e0"},
+		{name: "e3"},
+		{name: "x1"},
+		{name: "e"},
+	}
+	for _, tc := range testcases {
+		got, ok := s.Lookup(tc.name)
+		if got != tc.want || ok != tc.wantOk {
+			t.Errorf("Lookup(%q) = %q, %v; want %q, %v", tc.name, got, ok, tc.want, tc.wantOk)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package shortcuts describes the numbered list of files assigned shortcuts by
+// "scmpuff status", so that later uses of those shortcuts can verify they
+// still refer to what the user saw.
+package shortcuts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// FingerprintEnvVar is the environment variable in which the shell functions
+// record the Fingerprint of the most recent status.
+const FingerprintEnvVar = "SCMPUFF_FINGERPRINT"
+
+// A Fingerprint identifies a numbered list of shortcut files, and the
+// repository it was generated for.
+type Fingerprint struct {
+	Root string // absolute path of the repository root
+	Hash string // hash of the ordered list of absolute file paths
+}
+
+// NewFingerprint returns the Fingerprint for the numbered list of absolute
+// paths in the repository at root.
+func NewFingerprint(root string, paths []string) Fingerprint {
+	h := sha256.New()
+	for _, p := range paths {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return Fingerprint{
+		Root: root,
+		Hash: hex.EncodeToString(h.Sum(nil))[:16],
+	}
+}
+
+// String returns the serialized form of f, "<hash>:<root>".
+func (f Fingerprint) String() string {
+	return f.Hash + ":" + f.Root
+}
+
+// ParseFingerprint parses the serialized form of a Fingerprint, as returned by
+// Fingerprint.String.
+func ParseFingerprint(s string) (Fingerprint, error) {
+	// the hash never contains a colon, but the root path might
+	hash, root, ok := strings.Cut(s, ":")
+	if !ok || hash == "" || root == "" {
+		return Fingerprint{}, fmt.Errorf("malformed shortcut fingerprint %q", s)
+	}
+	return Fingerprint{Root: root, Hash: hash}, nil
+}
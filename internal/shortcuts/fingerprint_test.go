@@ -0,0 +1,53 @@
+package shortcuts
+
+import "testing"
+
+func TestNewFingerprint(t *testing.T) {
+	a := NewFingerprint("/repo", []string{"/repo/a.txt", "/repo/b.txt"})
+	if got := NewFingerprint("/repo", []string{"/repo/a.txt", "/repo/b.txt"}); got != a {
+		t.Errorf("NewFingerprint() not deterministic: %v != %v", got, a)
+	}
+
+	// any change to the numbering must change the hash
+	for _, paths := range [][]string{
+		{"/repo/b.txt", "/repo/a.txt"},
+		{"/repo/a.txt"},
+		{"/repo/a.txt", "/repo/b.txt", "/repo/c.txt"},
+		{"/repo/a.txt/repo/b.txt"},
+	} {
+		if got := NewFingerprint("/repo", paths); got.Hash == a.Hash {
+			t.Errorf("NewFingerprint(%q) has same hash as %q", paths, []string{"/repo/a.txt", "/repo/b.txt"})
+		}
+	}
+}
+
+func TestParseFingerprint(t *testing.T) {
+	testcases := []struct {
+		s       string
+		want    Fingerprint
+		wantErr bool
+	}{
+		{s: "0123456789abcdef:/repo", want: Fingerprint{Hash: "0123456789abcdef", Root: "/repo"}},
+		{s: "0123456789abcdef:C:/Users/bob/repo", want: Fingerprint{Hash: "0123456789abcdef", Root: "C:/Users/bob/repo"}},
+		{s: "", wantErr: true},
+		{s: "0123456789abcdef", wantErr: true},
+		{s: ":/repo", wantErr: true},
+		{s: "0123456789abcdef:", wantErr: true},
+	}
+	for _, tc := range testcases {
+		got, err := ParseFingerprint(tc.s)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseFingerprint(%q) error = %v, wantErr %v", tc.s, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseFingerprint(%q) = %v, want %v", tc.s, got, tc.want)
+		}
+	}
+
+	// round trip
+	f := NewFingerprint("/some:odd/root", []string{"/some:odd/root/x"})
+	if got, err := ParseFingerprint(f.String()); err != nil || got != f {
+		t.Errorf("ParseFingerprint(%q) = %v, %v; want %v", f.String(), got, err, f)
+	}
+}
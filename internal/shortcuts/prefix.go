@@ -0,0 +1,50 @@
+package shortcuts
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PrefixEnvVar is the environment variable holding the prefix of shortcut
+// variable names, as set by the shell functions (see "scmpuff init --prefix").
+const PrefixEnvVar = "SCMPUFF_PREFIX"
+
+// DefaultPrefix is the prefix of shortcut variable names when none is set,
+// giving the familiar $e1, $e2, etc.
+const DefaultPrefix = "e"
+
+var validPrefix = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidPrefix reports whether prefix can be used for shortcut variable names,
+// which must be valid shell variable names in all supported shells.
+func ValidPrefix(prefix string) bool {
+	return validPrefix.MatchString(prefix)
+}
+
+// Prefix returns the prefix of shortcut variable names in use. A prefix in the
+// environment that is not valid (see ValidPrefix) is ignored, falling back to
+// DefaultPrefix, as the shell functions do.
+func Prefix() string {
+	if prefix := os.Getenv(PrefixEnvVar); ValidPrefix(prefix) {
+		return prefix
+	}
+	return DefaultPrefix
+}
+
+// VarName returns the name of the variable for shortcut n (e.g. "e1").
+func VarName(n int) string {
+	return Prefix() + strconv.Itoa(n)
+}
+
+// ParseVarName returns the shortcut number of the variable name (e.g. 1 for
+// "e1"), and whether name is a shortcut variable at all.
+func ParseVarName(name string) (int, bool) {
+	digits, ok := strings.CutPrefix(name, Prefix())
+	if !ok || digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	return n, err == nil
+}
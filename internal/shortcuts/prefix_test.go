@@ -0,0 +1,57 @@
+package shortcuts
+
+import "testing"
+
+func TestParseVarName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		name   string
+		want   int
+		wantOk bool
+	}{
+		{prefix: "", name: "e1", want: 1, wantOk: true},
+		{prefix: "", name: "e42", want: 42, wantOk: true},
+		{prefix: "", name: "e", wantOk: false},
+		{prefix: "", name: "e+1", wantOk: false},
+		{prefix: "", name: "f1", wantOk: false},
+		{prefix: "sp_", name: "sp_3", want: 3, wantOk: true},
+		{prefix: "sp_", name: "e3", wantOk: false},
+		{prefix: ".*", name: "e1", want: 1, wantOk: true},
+		{prefix: ".*", name: ".*1", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Setenv(PrefixEnvVar, tt.prefix)
+		got, ok := ParseVarName(tt.name)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("ParseVarName(%q) with prefix %q = %v, %v; want %v, %v", tt.name, tt.prefix, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestVarName(t *testing.T) {
+	t.Setenv(PrefixEnvVar, "")
+	if got := VarName(7); got != "e7" {
+		t.Errorf("VarName(7) = %q, want e7", got)
+	}
+	t.Setenv(PrefixEnvVar, "sp_")
+	if got := VarName(7); got != "sp_7" {
+		t.Errorf("VarName(7) = %q, want sp_7", got)
+	}
+}
+
+func TestValidPrefix(t *testing.T) {
+	for prefix, want := range map[string]bool{
+		"e":     true,
+		"sp_":   true,
+		"_f2":   true,
+		"":      false,
+		"1e":    false,
+		"e-":    false,
+		"x y":   false,
+		"$(rm)": false,
+	} {
+		if got := ValidPrefix(prefix); got != want {
+			t.Errorf("ValidPrefix(%q) = %v, want %v", prefix, got, want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package shortcuts
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// StoreEnvVar is the environment variable set by the shell functions to "file"
+// when shortcuts are kept in the state file saved by each status (see Save),
+// rather than exported as environment variables.
+const StoreEnvVar = "SCMPUFF_SHORTCUTS"
+
+// A Resolver resolves shortcut variables (e.g. "e1") to the paths assigned to
+// them by "scmpuff status".
+type Resolver struct {
+	lookup      func(name string) (string, bool)
+	fingerprint Fingerprint // of the shortcuts being resolved, if known
+}
+
+// A Loader returns the current Set for the repository containing the working
+// directory, by running git status.
+type Loader func() (Set, error)
+
+// NewResolver returns the Resolver for shortcuts used within the working
+// directory wd.
+//
+// Shortcuts are normally resolved from the environment variables exported by
+// the shell functions. However, the environment is global to the shell while
+// shortcuts belong to a repository. If the fingerprint recorded along with them
+// (see FingerprintEnvVar) shows they were set in a different repository than
+// the one containing wd, the Set saved by the most recent status of this
+// repository is used instead, restoring the numbering last shown for it. If
+// there is none, an error is returned, as the environment can only refer to
+// the wrong files.
+//
+// When shortcuts are kept in the state file (see StoreEnvVar), the Set saved
+// for the repository containing wd is always used, and the environment is
+// ignored.
+//
+// If there is no shortcut state at all (e.g. in scripts, or other contexts
+// where scmpuff_status was never run), shortcuts are resolved from the current
+// Set as returned by current, so they match what status would display now.
+// It is only called once a shortcut is actually looked up.
+func NewResolver(wd string, current Loader) (*Resolver, error) {
+	if os.Getenv(StoreEnvVar) == "file" {
+		return newStateResolver(wd, current)
+	}
+
+	recorded := os.Getenv(FingerprintEnvVar)
+	if recorded == "" {
+		if !hasShortcutEnv() {
+			return newCurrentResolver(current), nil
+		}
+		// older shell functions, or shortcuts set by hand
+		return &Resolver{lookup: os.LookupEnv}, nil
+	}
+	fingerprint, err := ParseFingerprint(recorded)
+	if err != nil {
+		return nil, err
+	}
+	env := &Resolver{lookup: os.LookupEnv, fingerprint: fingerprint}
+
+	root, err := repoRoot(wd)
+	if err != nil || sameRepository(fingerprint.Root, root) {
+		// NOTE: outside of any repository, the absolute paths in the environment
+		// are as good as anywhere else (e.g. "scmpuff exec -- vim 1" from /tmp).
+		return env, nil
+	}
+
+	set, ok, err := Load(root)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("file shortcuts were set in a different repository (%s), run scmpuff_status to refresh them",
+			fingerprint.Root)
+	}
+	return &Resolver{lookup: set.Lookup, fingerprint: set.Fingerprint()}, nil
+}
+
+// newStateResolver returns the Resolver for the Set saved for the repository
+// containing wd, or for the current Set if there is none.
+func newStateResolver(wd string, current Loader) (*Resolver, error) {
+	root, err := repoRoot(wd)
+	if err != nil {
+		return newCurrentResolver(current), nil
+	}
+	set, ok, err := Load(root)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return newCurrentResolver(current), nil
+	}
+	return &Resolver{lookup: set.Lookup, fingerprint: set.Fingerprint()}, nil
+}
+
+// newCurrentResolver returns a Resolver for the current Set, which is loaded
+// the first time a shortcut is looked up. The fingerprint is left unknown, as
+// the Set is by definition up to date.
+func newCurrentResolver(current Loader) *Resolver {
+	var (
+		once sync.Once
+		set  Set
+	)
+	return &Resolver{lookup: func(name string) (string, bool) {
+		once.Do(func() {
+			// on error (e.g. outside of a repository), no shortcuts are assigned
+			set, _ = current()
+		})
+		return set.Lookup(name)
+	}}
+}
+
+// hasShortcutEnv reports whether any shortcut variables (e.g. $e1) are set in
+// the environment.
+func hasShortcutEnv() bool {
+	return slices.ContainsFunc(os.Environ(), func(kv string) bool {
+		name, _, _ := strings.Cut(kv, "=")
+		_, ok := ParseVarName(name)
+		return ok
+	})
+}
+
+// Lookup returns the path for the shortcut variable name (e.g. "e1"), and
+// whether it is assigned.
+func (r *Resolver) Lookup(name string) (string, bool) {
+	return r.lookup(name)
+}
+
+// Fingerprint returns the Fingerprint of the shortcuts being resolved, and
+// whether it is known.
+func (r *Resolver) Fingerprint() (Fingerprint, bool) {
+	return r.fingerprint, r.fingerprint != Fingerprint{}
+}
+
+// repoRoot returns the root of the git repository containing wd.
+//
+// The root is derived from wd rather than asking git for it, matching how
+// "scmpuff status" determines it, which keeps symlinked working directories
+// consistent.
+func repoRoot(wd string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-cdup")
+	cmd.Dir = wd
+	cdup, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Clean(filepath.Join(wd, string(bytes.TrimSpace(cdup)))), nil
+}
+
+// sameRepository reports whether the repository roots a and b are the same
+// directory, even if reached via different paths (e.g. through a symlink).
+func sameRepository(a, b string) bool {
+	if a == b {
+		return true
+	}
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
@@ -0,0 +1,50 @@
+package shortcuts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewResolverWithoutState(t *testing.T) {
+	t.Setenv(StoreEnvVar, "")
+	t.Setenv(FingerprintEnvVar, "")
+
+	calls := 0
+	current := func() (Set, error) {
+		calls++
+		return Set{Root: "/repo", Paths: []string{"/repo/a.txt"}}, nil
+	}
+	r, err := NewResolver(t.TempDir(), current)
+	if err != nil {
+		t.Fatalf("NewResolver() error: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("current Set loaded before any lookup")
+	}
+	if _, ok := r.Fingerprint(); ok {
+		t.Errorf("Fingerprint() known for current Set")
+	}
+	for range 2 {
+		if got, ok := r.Lookup("e1"); got != "/repo/a.txt" || !ok {
+			t.Errorf("Lookup(e1) = %q, %v; want /repo/a.txt, true", got, ok)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("current Set loaded %d times, want 1", calls)
+	}
+}
+
+func TestNewResolverEnvironment(t *testing.T) {
+	t.Setenv(StoreEnvVar, "")
+	t.Setenv(FingerprintEnvVar, "")
+	t.Setenv("e1", "/set/by/hand.txt")
+
+	current := func() (Set, error) { return Set{}, errors.New("should not be called") }
+	r, err := NewResolver(t.TempDir(), current)
+	if err != nil {
+		t.Fatalf("NewResolver() error: %v", err)
+	}
+	if got, _ := r.Lookup("e1"); got != "/set/by/hand.txt" {
+		t.Errorf("Lookup(e1) = %q, want value from environment", got)
+	}
+}
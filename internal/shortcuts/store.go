@@ -0,0 +1,96 @@
+package shortcuts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// stateDir returns the directory in which scmpuff keeps its state, following
+// the XDG Base Directory specification.
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "scmpuff"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate state directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "scmpuff"), nil
+}
+
+// repoFile returns the path of the file in which the shortcut Set for the
+// repository at root is saved.
+func repoFile(root string) (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	// resolve symlinks so that the same repository reached via different paths
+	// shares a single file
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dir, "repos", hex.EncodeToString(sum[:8])), nil
+}
+
+// Save records s as the most recent shortcut Set for its repository, so that
+// it can be restored with Load.
+func Save(s Set) error {
+	path, err := repoFile(s.Root)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := WriteFilelist(&buf, s); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	// write to a temporary file and rename, so that concurrent readers never see
+	// a partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save shortcuts: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save shortcuts: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save shortcuts: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load returns the shortcut Set most recently saved for the repository at
+// root, and whether there was one.
+func Load(root string) (Set, bool, error) {
+	path, err := repoFile(root)
+	if err != nil {
+		return Set{}, false, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Set{}, false, nil
+	}
+	if err != nil {
+		return Set{}, false, fmt.Errorf("failed to load shortcuts: %w", err)
+	}
+	defer f.Close()
+
+	s, err := ReadFilelist(f)
+	if err != nil {
+		return Set{}, false, fmt.Errorf("failed to load shortcuts from %s: %w", path, err)
+	}
+	return s, true, nil
+}
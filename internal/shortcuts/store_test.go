@@ -0,0 +1,44 @@
+package shortcuts
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveLoad(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repoA, repoB := t.TempDir(), t.TempDir()
+
+	if _, ok, err := Load(repoA); err != nil || ok {
+		t.Fatalf("Load() before Save() = _, %v, %v; want nothing", ok, err)
+	}
+
+	a := Set{Root: repoA, Paths: []string{repoA + "/a.txt"}}
+	b := Set{Root: repoB, Paths: []string{repoB + "/b.txt", repoB + "/c.txt"}}
+	for _, s := range []Set{a, b} {
+		if err := Save(s); err != nil {
+			t.Fatalf("Save() error: %v", err)
+		}
+	}
+
+	// each repository keeps its own set
+	for _, want := range []Set{a, b} {
+		got, ok, err := Load(want.Root)
+		if err != nil || !ok {
+			t.Fatalf("Load(%s) = _, %v, %v", want.Root, ok, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Load(%s) mismatch (-want +got):\n%s", want.Root, diff)
+		}
+	}
+
+	// saving again replaces the set
+	a2 := Set{Root: repoA, Paths: []string{}}
+	if err := Save(a2); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if got, _, _ := Load(repoA); !cmp.Equal(a2, got) {
+		t.Errorf("Load() after replacing = %v, want %v", got, a2)
+	}
+}
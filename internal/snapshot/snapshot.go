@@ -0,0 +1,261 @@
+// Package snapshot keeps safety backups of working tree files, so that changes
+// discarded by destructive git commands (e.g. "git checkout <path>") can be
+// recovered with "scmpuff undo".
+//
+// File contents are stored as blobs in the git object database (via "git
+// hash-object -w"), and a small journal describing each snapshot is kept in
+// the "scmpuff" directory of the repository's git dir.
+//
+// NOTE: the blobs are not referenced by anything, so they will eventually be
+// removed by "git gc" once they are older than gc.pruneExpire (two weeks by
+// default). Snapshots are a safety net for recent mistakes, not an archive.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxSnapshots is the number of snapshots retained in the journal, older ones
+// are pruned when a new snapshot is saved.
+const maxSnapshots = 50
+
+// journalFile is the name of the journal file within the journal directory.
+const journalFile = "snapshots.jsonl"
+
+// A Snapshot is a backup of one or more files taken at a point in time.
+type Snapshot struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"` // description of what triggered the snapshot
+	Files   []File    `json:"files"`
+}
+
+// A File is the backup of a single file within a Snapshot.
+type File struct {
+	Path string      `json:"path"` // relative to the repo root, slash separated
+	Blob string      `json:"blob"` // git object id of the file contents
+	Mode fs.FileMode `json:"mode"`
+}
+
+// A Journal records snapshots for a single git repository.
+type Journal struct {
+	root string // absolute path of the repository root
+	dir  string // absolute path of the directory containing the journal
+}
+
+// Open returns the Journal for the git repository containing the working
+// directory wd.
+func Open(wd string) (*Journal, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-cdup", "--git-path", "scmpuff")
+	cmd.Dir = wd
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate git repository: %w", err)
+	}
+
+	// NOTE: --show-cdup prints an empty line at the repository root
+	cdup, gitPath, ok := strings.Cut(strings.TrimSuffix(string(out), "\n"), "\n")
+	if !ok {
+		return nil, fmt.Errorf("unexpected git rev-parse output: %q", out)
+	}
+	if !filepath.IsAbs(gitPath) {
+		gitPath = filepath.Join(wd, gitPath)
+	}
+
+	return &Journal{
+		root: filepath.Clean(filepath.Join(wd, cdup)),
+		dir:  filepath.Clean(gitPath),
+	}, nil
+}
+
+// Save snapshots the current contents of the files at absPaths, recording
+// command as the reason, and returns the resulting Snapshot.
+//
+// A directory stands for the files within it that git knows about, tracked or
+// untracked but not ignored, as those are the ones a git command may discard.
+// Paths that do not exist, are not regular files (e.g. symlinks), or are
+// outside of the repository are skipped, as there is nothing to back up. If no
+// files remain, no snapshot is recorded and a nil Snapshot is returned.
+func (j *Journal) Save(command string, absPaths []string) (*Snapshot, error) {
+	absPaths, err := j.expandDirs(absPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files []File
+		paths []string
+		seen  = make(map[string]bool)
+	)
+	for _, p := range absPaths {
+		fi, err := os.Lstat(p)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		relPath, ok := j.relPath(p)
+		if !ok || seen[relPath] {
+			continue
+		}
+		seen[relPath] = true
+		files = append(files, File{Path: relPath, Mode: fi.Mode().Perm()})
+		paths = append(paths, p)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	// --no-filters stores the exact bytes on disk, so they can be restored as-is
+	args := append([]string{"hash-object", "-w", "--no-filters", "--"}, paths...)
+	out, err := j.git(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file contents: %w", err)
+	}
+	blobs := strings.Fields(string(out))
+	if len(blobs) != len(files) {
+		return nil, fmt.Errorf("unexpected git hash-object output: %d ids for %d files", len(blobs), len(files))
+	}
+	for i := range files {
+		files[i].Blob = blobs[i]
+	}
+
+	snap := Snapshot{Time: time.Now(), Command: command, Files: files}
+	snapshots, err := j.List()
+	if err != nil {
+		return nil, err
+	}
+	snapshots = append([]Snapshot{snap}, snapshots...)
+	if err := j.write(snapshots[:min(len(snapshots), maxSnapshots)]); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// expandDirs returns absPaths with each directory replaced by the files within
+// it that are tracked, or untracked but not ignored, as listed by git.
+func (j *Journal) expandDirs(absPaths []string) ([]string, error) {
+	var paths, dirs []string
+	for _, p := range absPaths {
+		fi, err := os.Lstat(p)
+		switch _, ok := j.relPath(p); {
+		case err == nil && fi.IsDir() && ok:
+			dirs = append(dirs, p)
+		case err == nil && fi.IsDir():
+			// outside of the repository, which git would refuse to list
+		default:
+			paths = append(paths, p)
+		}
+	}
+	if len(dirs) == 0 {
+		return paths, nil
+	}
+
+	args := append([]string{"ls-files", "-z", "--cached", "--others", "--exclude-standard", "--"}, dirs...)
+	out, err := j.git(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", strings.Join(dirs, ", "), err)
+	}
+	for relPath := range strings.SplitSeq(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+		if relPath != "" {
+			paths = append(paths, filepath.Join(j.root, filepath.FromSlash(relPath)))
+		}
+	}
+	return paths, nil
+}
+
+// relPath returns absPath relative to the repository root, slash separated,
+// and whether it is within the repository at all.
+func (j *Journal) relPath(absPath string) (string, bool) {
+	relPath, err := filepath.Rel(j.root, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(relPath), true
+}
+
+// List returns the recorded snapshots, most recent first.
+func (j *Journal) List() ([]Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(j.dir, journalFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot journal: %w", err)
+	}
+
+	var snapshots []Snapshot
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var snap Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot journal: %w", err)
+		}
+		snapshots = append(snapshots, snap)
+	}
+	return snapshots, scanner.Err()
+}
+
+// write replaces the journal contents with snapshots.
+func (j *Journal) write(snapshots []Snapshot) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, snap := range snapshots {
+		if err := enc.Encode(snap); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(j.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	// write to a temporary file and rename, so the journal is never left
+	// partially written
+	tmp := filepath.Join(j.dir, journalFile+".tmp")
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot journal: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(j.dir, journalFile))
+}
+
+// Restore writes the backed up contents of f back to the working tree,
+// returning the absolute path written.
+func (j *Journal) Restore(f File) (string, error) {
+	contents, err := j.git("cat-file", "blob", f.Blob).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read backup of %s (it may have been garbage collected): %w", f.Path, err)
+	}
+
+	absPath := filepath.Join(j.root, filepath.FromSlash(f.Path))
+	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", f.Path, err)
+	}
+	if err := os.WriteFile(absPath, contents, f.Mode); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", f.Path, err)
+	}
+	// WriteFile only applies the mode to newly created files
+	if err := os.Chmod(absPath, f.Mode); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", f.Path, err)
+	}
+	return absPath, nil
+}
+
+// git returns a command to run git with args within the repository.
+func (j *Journal) git(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = j.root
+	return cmd
+}
+
+// Root returns the absolute path of the repository root.
+func (j *Journal) Root() string {
+	return j.root
+}
@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// setupRepo creates an empty git repository with a subdirectory, isolated
+// from the host git configuration, and returns its path.
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestJournal_SaveRestore(t *testing.T) {
+	root := setupRepo(t)
+	path := filepath.Join(root, "sub", "a.txt")
+	if err := os.WriteFile(path, []byte("precious work\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// open from a subdirectory, to make sure paths are relative to the root
+	journal, err := Open(filepath.Join(root, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := journal.Save("git checkout 1", []string{path, filepath.Join(root, "sub"), filepath.Join(root, "missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Files) != 1 || snap.Files[0].Path != "sub/a.txt" {
+		t.Fatalf("expected only sub/a.txt in snapshot, got %+v", snap.Files)
+	}
+
+	snapshots, err := journal.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Command != "git checkout 1" {
+		t.Fatalf("unexpected snapshots: %+v", snapshots)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Restore(snapshots[0].Files[0]); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "precious work\n" {
+		t.Errorf("restored contents = %q", contents)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o755 {
+		t.Errorf("restored mode = %v, err = %v", fi.Mode().Perm(), err)
+	}
+}
+
+func TestJournal_SaveDirectory(t *testing.T) {
+	root := setupRepo(t)
+	for path, contents := range map[string]string{
+		".gitignore":        "*.log\n",
+		"sub/tracked.txt":   "tracked\n",
+		"sub/deep/new.txt":  "untracked\n",
+		"sub/ignored.log":   "ignored\n",
+		"other/outside.txt": "elsewhere\n",
+	} {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out, err := exec.Command("git", "-C", root, "add", "sub/tracked.txt").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+
+	journal, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := journal.Save("git checkout -- sub", []string{filepath.Join(root, "sub"), filepath.Join(root, "sub", "tracked.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ignored files are left out, and files listed twice are only saved once
+	var got []string
+	for _, f := range snap.Files {
+		got = append(got, f.Path)
+	}
+	slices.Sort(got)
+	if want := []string{"sub/deep/new.txt", "sub/tracked.txt"}; !slices.Equal(got, want) {
+		t.Errorf("snapshot files = %q, want %q", got, want)
+	}
+}
+
+func TestJournal_SavePrunes(t *testing.T) {
+	root := setupRepo(t)
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	journal, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range maxSnapshots + 2 {
+		if _, err := journal.Save("test", []string{path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshots, err := journal.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != maxSnapshots {
+		t.Errorf("got %d snapshots, want %d", len(snapshots), maxSnapshots)
+	}
+}